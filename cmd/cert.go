@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Client and registry certificates",
+}
+
+var certStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show validity of the client certificate and the certificates presented by the registry",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getRegistryClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "Client certificate:")
+		if cert := client.ClientCertificate(); cert != nil {
+			printCertificate(w, cert)
+		}
+
+		// The registry's chain is shown even when it does not verify, as
+		// that is when it is needed the most.
+		connErr := client.Connect()
+		if connErr == nil {
+			defer client.Close()
+		}
+
+		for i, cert := range client.ServerCertificates() {
+			fmt.Fprintf(w, "\nRegistry certificate %d:\n", i+1)
+			printCertificate(w, cert)
+		}
+		_ = w.Flush()
+
+		if connErr != nil {
+			fmt.Println()
+			return connErr
+		}
+
+		return nil
+	},
+}
+
+func printCertificate(w io.Writer, cert *x509.Certificate) {
+	fmt.Fprintf(w, "%s\t%s\n", "Subject:", cert.Subject)
+	fmt.Fprintf(w, "%s\t%s\n", "Issuer:", cert.Issuer)
+	fmt.Fprintf(w, "%s\t%s\n", "Valid from:", cert.NotBefore.Local())
+	fmt.Fprintf(w, "%s\t%s\n", "Valid until:", cert.NotAfter.Local())
+
+	remaining := time.Until(cert.NotAfter)
+	if remaining <= 0 {
+		fmt.Fprintf(w, "%s\t%s\n", "Days remaining:", "expired")
+	} else {
+		fmt.Fprintf(w, "%s\t%d\n", "Days remaining:", int(remaining.Hours()/24))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(certCmd)
+	certCmd.AddCommand(certStatusCmd)
+}
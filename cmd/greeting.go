@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var greetingCmd = &cobra.Command{
+	Use:   "greeting",
+	Short: "Show server greeting, including services and data collection policy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := getRegistryClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err = client.Connect(); err != nil {
+			return errors.Wrap(err, "Unable to connect")
+		}
+		defer client.Close()
+
+		greeting := client.ServerGreeting()
+
+		printJson, _ := cmd.Flags().GetBool("json")
+
+		if printJson {
+			jsonGreeting, err := json.MarshalIndent(greeting, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, "Unable to create JSON greeting")
+			}
+			fmt.Println(string(jsonGreeting))
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 2, 8, 2, ' ', 0)
+			fmt.Fprintf(w, "%s\t%s\n", "Server:", greeting.SvID)
+			fmt.Fprintf(w, "%s\t%s\n", "Time:", greeting.SvDate)
+			fmt.Fprintf(w, "%s\t%s\n", "Version:", greeting.SvcMenu.Version)
+			fmt.Fprintf(w, "%s\t%s\n", "Language:", greeting.SvcMenu.Lang)
+			for _, objURI := range greeting.SvcMenu.ObjURI {
+				fmt.Fprintf(w, "%s\t%s\n", "Object:", objURI)
+			}
+			for _, extURI := range greeting.SvcMenu.SvcExtension.ExtURI {
+				fmt.Fprintf(w, "%s\t%s\n", "Extension:", extURI)
+			}
+
+			dcp := greeting.DCP
+			fmt.Fprintf(w, "%s\t%s\n", "Data access:", strings.Join(dcp.Access, ", "))
+			for i, statement := range dcp.Statements {
+				fmt.Fprintf(w, "Statement %d:\t%s %s\n", i+1, "purpose", strings.Join(statement.Purpose, ", "))
+				fmt.Fprintf(w, "\t%s %s\n", "recipient", strings.Join(statement.Recipient, ", "))
+				fmt.Fprintf(w, "\t%s %s\n", "retention", strings.Join(statement.Retention, ", "))
+			}
+			if dcp.Expiry != nil {
+				fmt.Fprintf(w, "%s\t%s%s\n", "Policy expiry:", dcp.Expiry.Absolute, dcp.Expiry.Relative)
+			}
+
+			_ = w.Flush()
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(greetingCmd)
+	greetingCmd.Flags().BoolP("json", "j", false, "Show greeting as JSON")
+}
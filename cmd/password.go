@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ajmyyra/go-epp-fi/pkg/registry"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var passwordCmd = &cobra.Command{
+	Use:   "password",
+	Short: "Change the account password",
+}
+
+var passwordChangeCmd = &cobra.Command{
+	Use:   "change",
+	Short: "Change the account password, optionally generating a new one",
+	Long: `Change the account password. The new password is asked for when running in
+a terminal, and read from stdin otherwise, so that it is not left in the shell
+history. With --generate, a new password is generated instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generate, _ := cmd.Flags().GetBool("generate")
+		writeConfig, _ := cmd.Flags().GetBool("write-config")
+
+		var newPassword string
+		var err error
+		if generate {
+			newPassword, err = registry.GeneratePassword()
+		} else {
+			newPassword, err = readNewPassword()
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = registry.ValidatePassword(newPassword); err != nil {
+			return err
+		}
+
+		client, err := getRegistryClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err = client.Connect(); err != nil {
+			return errors.Wrap(err, "Unable to connect")
+		}
+		defer client.Close()
+
+		if err = client.ChangePassword(newPassword); err != nil {
+			return errors.Wrap(err, "Unable to change password")
+		}
+
+		fmt.Println("Password changed successfully.")
+
+		if writeConfig {
+			if err = writeConfigPassword(newPassword); err != nil {
+				fmt.Printf("New password: %s\n", newPassword)
+				return err
+			}
+			fmt.Printf("New password saved to %s.\n", configFile)
+		} else if generate {
+			fmt.Printf("New password: %s\n", newPassword)
+		}
+
+		return nil
+	},
+}
+
+// readNewPassword asks for the new password twice when running in a terminal,
+// and otherwise reads it from stdin.
+func readNewPassword() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		password, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", errors.Wrap(err, "Unable to read new password from stdin")
+		}
+		if len(password) == 0 {
+			return "", errors.New("New password or --generate is required")
+		}
+		return strings.TrimRight(string(password), "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "New password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to read new password")
+	}
+
+	fmt.Fprint(os.Stderr, "Repeat new password: ")
+	repeated, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to read new password")
+	}
+	if string(password) != string(repeated) {
+		return "", errors.New("Passwords do not match")
+	}
+
+	return string(password), nil
+}
+
+// writeConfigPassword replaces the password in the config file, keeping the
+// other settings as they are. Settings from environment are not written.
+func writeConfigPassword(password string) error {
+	fileConfig := viper.New()
+	fileConfig.SetConfigType("yaml")
+	fileConfig.SetConfigFile(configFile)
+
+	// Missing config file is created with only the password.
+	if _, err := os.Stat(configFile); err == nil {
+		if err = fileConfig.ReadInConfig(); err != nil {
+			return errors.Wrap(err, "Unable to read "+configFile)
+		}
+	}
+
+	fileConfig.Set("PASSWORD", password)
+	if err := fileConfig.WriteConfig(); err != nil {
+		return errors.Wrap(err, "Unable to write new password to "+configFile)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(passwordCmd)
+	passwordCmd.AddCommand(passwordChangeCmd)
+	passwordChangeCmd.Flags().BoolP("generate", "g", false, "Generate a new password fulfilling the registry's complexity rules.")
+	passwordChangeCmd.Flags().BoolP("write-config", "w", false, "Write the new password to the config file.")
+}
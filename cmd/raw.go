@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var rawCmd = &cobra.Command{
+	Use:   "raw <file|->",
+	Short: "Send an EPP XML document to the registry and print the response",
+	Long: `Send an EPP XML document, read from a file or from stdin with -, to the
+registry and print the response. The client logs in before sending the document
+unless --no-login is given, and like other commands leaves the session open
+afterwards. {{clTRID}} in the document is replaced with a new transaction ID.
+Exits with an error if the registry responds with an error code.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var payload []byte
+		var err error
+		if args[0] == "-" {
+			payload, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			payload, err = ioutil.ReadFile(args[0])
+		}
+		if err != nil {
+			return errors.Wrap(err, "Unable to read XML document")
+		}
+
+		client, err := getRegistryClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err = client.Connect(); err != nil {
+			return errors.Wrap(err, "Unable to connect")
+		}
+		defer client.Close()
+
+		if noLogin, _ := cmd.Flags().GetBool("no-login"); !noLogin {
+			if err = client.Login(); err != nil {
+				return errors.Wrap(err, "Unable to log in")
+			}
+		}
+
+		raw, _, sendErr := client.SendRaw(payload)
+		if raw != nil {
+			pretty, err := prettyXML(raw)
+			if err != nil {
+				pretty = string(raw)
+			}
+			fmt.Println(pretty)
+		}
+
+		return sendErr
+	},
+}
+
+// prettyXML indents an XML document, keeping the namespace prefixes as they
+// were, unlike re-encoding it with xml.Encoder. Elements with only text are
+// printed on a single line.
+func prettyXML(data []byte) (string, error) {
+	var out strings.Builder
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	depth := 0
+	open := false     // start tag written without its closing >
+	children := false // current element has child elements
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if _, isEnd := token.(xml.EndElement); open && !isEnd {
+			out.WriteString(">")
+			open = false
+		}
+
+		switch t := token.(type) {
+		case xml.ProcInst:
+			fmt.Fprintf(&out, "<?%s %s?>", t.Target, t.Inst)
+		case xml.StartElement:
+			writeIndent(&out, depth)
+			out.WriteString("<" + rawName(t.Name))
+			for _, attr := range t.Attr {
+				out.WriteString(" " + rawName(attr.Name) + `="`)
+				_ = xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteString(`"`)
+			}
+			open = true
+			children = false
+			depth++
+		case xml.EndElement:
+			depth--
+			switch {
+			case open:
+				out.WriteString(" />")
+				open = false
+			case children:
+				writeIndent(&out, depth)
+				fallthrough
+			default:
+				out.WriteString("</" + rawName(t.Name) + ">")
+			}
+			children = true
+		case xml.CharData:
+			if text := bytes.TrimSpace(t); len(text) > 0 {
+				_ = xml.EscapeText(&out, text)
+			}
+		case xml.Comment:
+			writeIndent(&out, depth)
+			out.WriteString("<!--" + string(t) + "-->")
+			children = true
+		}
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+func writeIndent(out *strings.Builder, depth int) {
+	out.WriteString("\n" + strings.Repeat("  ", depth))
+}
+
+func rawName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func init() {
+	rootCmd.AddCommand(rawCmd)
+	rawCmd.Flags().Bool("no-login", false, "Send the document without logging in, e.g. for a login or hello")
+}
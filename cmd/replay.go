@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/ajmyyra/go-epp-fi/pkg/registry"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Rerun a session recorded with FI_EPP_TRANSCRIPT against its recorded responses",
+	Long: `Rerun a session recorded with FI_EPP_TRANSCRIPT against its recorded responses.
+The recorded commands are sent through the client without connecting to the
+registry, which makes it possible to reproduce an issue locally.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return errors.Wrap(err, "Unable to open transcript")
+		}
+		defer file.Close()
+
+		entries, err := registry.ReadTranscript(file)
+		if err != nil {
+			return err
+		}
+
+		// Passwords are masked in the transcript, and the recorded login is
+		// replayed as is, so the credentials are not used.
+		client, err := registry.New(
+			registry.WithCredentials("replay", ""),
+			registry.WithTransport(registry.NewReplayTransport(entries)),
+			registry.WithLogger(newLogger()),
+		)
+		if err != nil {
+			return errors.Wrap(err, "Unable to create replay client")
+		}
+
+		printXML, _ := cmd.Flags().GetBool("xml")
+		connected := false
+		for _, entry := range entries {
+			switch entry.Kind {
+			case registry.TranscriptConnect:
+				if err = client.Connect(); err != nil {
+					return errors.Wrap(err, "Unable to replay connection")
+				}
+				connected = true
+				fmt.Printf("Connected: %s\n", client.ServerGreeting().SvID)
+			case registry.TranscriptClose:
+				if connected {
+					_ = client.Close()
+					connected = false
+				}
+				fmt.Println("Closed")
+			case registry.TranscriptSend:
+				// Failed commands are replayed as they were recorded.
+				raw, resp, err := client.SendRaw([]byte(entry.Payload))
+				var eppErr *registry.EPPError
+				if err != nil && !errors.As(err, &eppErr) {
+					return errors.Wrapf(err, "Replaying %s failed", entry.Command())
+				}
+
+				if result := resp.Results.Primary(); len(resp.Results) > 0 {
+					fmt.Printf("%s: %d %s\n", entry.Command(), result.Code, result.Msg)
+				} else {
+					fmt.Printf("%s: greeting\n", entry.Command())
+				}
+				if printXML {
+					fmt.Println(string(raw))
+				}
+			}
+		}
+		if connected {
+			_ = client.Close()
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+	replayCmd.Flags().Bool("xml", false, "Print the replayed responses as XML")
+}
@@ -2,13 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/ajmyyra/go-epp-fi/pkg/registry"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
 )
 
 var configFile string
-var configVars = []string{"CLIENT_KEY", "CLIENT_CERT", "USERNAME", "PASSWORD", "SERVER"}
+var configVars = []string{
+	"CLIENT_KEY", "CLIENT_CERT", "KEY_PASSPHRASE", "KEY_PASSPHRASE_FILE", "USERNAME", "PASSWORD", "SERVER",
+	"LOG_LEVEL", "LOG_UNSAFE", "PROXY", "CERT_WARNING_DAYS", "TRANSCRIPT",
+	"TLS_MIN_VERSION", "TLS_CIPHER_SUITES", "TLS_SERVER_NAME", "TLS_PINS",
+}
 var envPrefix = "FI_EPP"
 
 var rootCmd = &cobra.Command{
@@ -19,6 +25,13 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
+
+		// Registry support needs the server transaction ID to find the request.
+		var eppErr *registry.EPPError
+		if errors.As(err, &eppErr) && eppErr.SvTRID != "" {
+			fmt.Printf("Server transaction ID: %s (client transaction ID: %s)\n", eppErr.SvTRID, eppErr.ClTRID)
+		}
+
 		os.Exit(1)
 	}
 }
@@ -6,19 +6,82 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 func getRegistryClient(cmd *cobra.Command) (*registry.Client, error) {
-	keyPath, err := getConfigString("CLIENT_KEY")
+	certOpt, err := getClientCertificate()
 	if err != nil {
 		return nil, err
 	}
-	clientKey, err := ioutil.ReadFile(keyPath)
+
+	server, err := getConfigString("SERVER")
 	if err != nil {
-		return nil, errors.Wrap(err, "Unable to load client key from " + keyPath)
+		return nil, err
 	}
 
+	username, err := getConfigString("USERNAME")
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := getConfigString("PASSWORD")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []registry.Option{
+		registry.WithServer(server, 700),
+		registry.WithCredentials(username, password),
+		certOpt,
+		registry.WithLogger(newLogger()),
+	}
+	if viper.IsSet("CERT_WARNING_DAYS") {
+		days := viper.GetInt("CERT_WARNING_DAYS")
+		opts = append(opts, registry.WithExpiryWarning(time.Duration(days)*24*time.Hour))
+	}
+	if viper.GetBool("LOG_UNSAFE") {
+		opts = append(opts, registry.WithUnsafeDebugLogging())
+	}
+	tlsOpts, err := getTLSOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, tlsOpts...)
+
+	if proxyURL := viper.GetString("PROXY"); proxyURL != "" {
+		dialer, err := registry.NewProxyDialer(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, registry.WithDialer(dialer))
+	}
+
+	// The transcript is appended to, so that consecutive commands can be
+	// replayed together. It is closed when the client is closed.
+	if path := viper.GetString("TRANSCRIPT"); path != "" {
+		opts = append(opts, registry.WithTranscriptFile(path))
+	}
+
+	client, err := registry.New(opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to create registry client")
+	}
+
+	return client, nil
+}
+
+// getClientCertificate loads the client certificate from CLIENT_CERT, either
+// a PEM file with the key in CLIENT_KEY or a .p12/.pfx bundle. Passphrases of
+// encrypted keys and bundles are read from KEY_PASSPHRASE, the file in
+// KEY_PASSPHRASE_FILE or asked interactively.
+func getClientCertificate() (registry.Option, error) {
 	certPath, err := getConfigString("CLIENT_CERT")
 	if err != nil {
 		return nil, err
@@ -28,24 +91,122 @@ func getRegistryClient(cmd *cobra.Command) (*registry.Client, error) {
 		return nil, errors.Wrap(err, "Unable to load client certificate from " + certPath)
 	}
 
-	server, err := getConfigString("SERVER")
+	passphrase, err := getKeyPassphrase()
 	if err != nil {
 		return nil, err
 	}
 
-	username, err := getConfigString("USERNAME")
+	switch strings.ToLower(filepath.Ext(certPath)) {
+	case ".p12", ".pfx":
+		// Bundles without a passphrase are tried before asking for one.
+		_, err = registry.LoadPKCS12(clientCert, passphrase)
+		if errors.Is(err, registry.ErrPassphraseRequired) {
+			if passphrase, err = promptPassphrase(certPath); err != nil {
+				return nil, err
+			}
+			_, err = registry.LoadPKCS12(clientCert, passphrase)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to load client certificate from " + certPath)
+		}
+		return registry.WithPKCS12(clientCert, passphrase), nil
+	}
+
+	keyPath, err := getConfigString("CLIENT_KEY")
 	if err != nil {
 		return nil, err
 	}
+	clientKey, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to load client key from " + keyPath)
+	}
 
-	password, err := getConfigString("PASSWORD")
+	if !registry.IsEncryptedKey(clientKey) {
+		return registry.WithClientCertificate(clientCert, clientKey), nil
+	}
+	if passphrase == "" {
+		if passphrase, err = promptPassphrase(keyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry.WithEncryptedClientCertificate(clientCert, clientKey, passphrase), nil
+}
+
+func getKeyPassphrase() (string, error) {
+	if passphrase := viper.GetString("KEY_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	path := viper.GetString("KEY_PASSPHRASE_FILE")
+	if path == "" {
+		return "", nil
+	}
+	passphrase, err := ioutil.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return "", errors.Wrap(err, "Unable to read key passphrase from " + path)
 	}
 
-	client, err := registry.NewRegistryClient(username, password, server, 700, clientKey, clientCert)
+	return strings.TrimRight(string(passphrase), "\r\n"), nil
+}
 
-	return client, nil
+// promptPassphrase asks for the passphrase of file when running in a terminal.
+func promptPassphrase(file string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.Errorf("Passphrase for %s required, set KEY_PASSPHRASE or KEY_PASSPHRASE_FILE", file)
+	}
+
+	fmt.Fprintf(os.Stderr, "Passphrase for %s: ", file)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to read passphrase")
+	}
+
+	return string(passphrase), nil
+}
+
+// getTLSOptions reads the optional TLS policy: minimum version (e.g. 1.3),
+// comma separated cipher suite names and pins, and server name.
+func getTLSOptions() ([]registry.Option, error) {
+	var opts []registry.Option
+
+	if minVersion := viper.GetString("TLS_MIN_VERSION"); minVersion != "" {
+		version, err := registry.ParseTLSVersion(minVersion)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, registry.WithMinTLSVersion(version))
+	}
+
+	if cipherSuites := viper.GetString("TLS_CIPHER_SUITES"); cipherSuites != "" {
+		suites, err := registry.ParseCipherSuites(strings.Split(cipherSuites, ","))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, registry.WithCipherSuites(suites...))
+	}
+
+	if serverName := viper.GetString("TLS_SERVER_NAME"); serverName != "" {
+		opts = append(opts, registry.WithServerName(serverName))
+	}
+
+	if pins := viper.GetString("TLS_PINS"); pins != "" {
+		opts = append(opts, registry.WithPinnedKeys(strings.Split(pins, ",")...))
+	}
+
+	return opts, nil
+}
+
+// newLogger logs to stderr on the level set with LOG_LEVEL (debug, info, warn
+// or error), defaulting to info.
+func newLogger() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(viper.GetString("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 }
 
 func getConfigString(name string) (string, error) {
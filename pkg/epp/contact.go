@@ -38,7 +38,7 @@ type APIContactInfoResponse struct {
 	XMLName  xml.Name `xml:"epp"`
 	Xmlns    string   `xml:"xmlns,attr"`
 	Response struct {
-		Result Result `xml:"result"`
+		Results Results `xml:"result"`
 		ResData struct {
 			ContactInfo ContactResponse `xml:"infData"`
 		} `xml:"resData"`
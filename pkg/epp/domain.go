@@ -50,7 +50,7 @@ type APIDomainInfoResponse struct {
 	XMLName  xml.Name `xml:"epp"`
 	Xmlns    string   `xml:"xmlns,attr"`
 	Response struct {
-		Result Result `xml:"result"`
+		Results Results `xml:"result"`
 		ResData struct {
 			DomainInfo DomainInfoResp `xml:"infData"`
 		} `xml:"resData"`
@@ -16,15 +16,73 @@ type APIGreeting struct {
 }
 
 type Greeting struct {
-	SvID    string `xml:"svID"`
-	RawDate string `xml:"svDate"`
-	SvDate  time.Time
+	SvID    string    `xml:"svID" json:"server_id"`
+	RawDate string    `xml:"svDate" json:"-"`
+	SvDate  time.Time `json:"server_date"`
 	SvcMenu struct {
-		Version      string   `xml:"version"`
-		Lang         string   `xml:"lang"`
-		ObjURI       []string `xml:"objURI"`
+		Version      string   `xml:"version" json:"version"`
+		Lang         string   `xml:"lang" json:"lang"`
+		ObjURI       []string `xml:"objURI" json:"obj_uri"`
 		SvcExtension struct {
-			ExtURI []string `xml:"extURI,omitempty"`
-		} `xml:"svcExtension"`
-	} `xml:"svcMenu"`
+			ExtURI []string `xml:"extURI,omitempty" json:"ext_uri"`
+		} `xml:"svcExtension" json:"svc_extension"`
+	} `xml:"svcMenu" json:"services"`
+	DCP DCP `xml:"dcp" json:"data_collection_policy"`
+}
+
+// DCP is the data collection policy of the server, describing which data is
+// collected, why, who can see it and how long it is kept (RFC 5730 2.4).
+type DCP struct {
+	// Access is one of all, none, null, other, personal or personalAndOther.
+	Access     DCPValues      `xml:"access" json:"access"`
+	Statements []DCPStatement `xml:"statement" json:"statements"`
+	Expiry     *DCPExpiry     `xml:"expiry" json:"expiry,omitempty"`
+}
+
+type DCPStatement struct {
+	// Purpose contains admin, contact, other and/or prov.
+	Purpose DCPValues `xml:"purpose" json:"purpose"`
+	// Recipient contains other, ours, public, same and/or unrelated. Ours may
+	// be followed by its description in parentheses.
+	Recipient DCPValues `xml:"recipient" json:"recipient"`
+	// Retention is one of business, indefinite, legal, none or stated.
+	Retention DCPValues `xml:"retention" json:"retention"`
+}
+
+// DCPExpiry tells when the policy expires, either as a date or as a duration
+// (e.g. P1Y) from when it was received.
+type DCPExpiry struct {
+	Absolute string `xml:"absolute" json:"absolute,omitempty"`
+	Relative string `xml:"relative" json:"relative,omitempty"`
+}
+
+// DCPValues holds the names of the empty elements used as values in DCP,
+// e.g. <recipient><ours/><public/></recipient> becomes [ours public].
+type DCPValues []string
+
+func (v *DCPValues) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var element struct {
+				RecDesc string `xml:"recDesc"`
+			}
+			if err = d.DecodeElement(&element, &t); err != nil {
+				return err
+			}
+
+			value := t.Name.Local
+			if element.RecDesc != "" {
+				value += " (" + element.RecDesc + ")"
+			}
+			*v = append(*v, value)
+		case xml.EndElement:
+			return nil
+		}
+	}
 }
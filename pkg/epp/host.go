@@ -39,10 +39,7 @@ type APIHostInfoResponse struct {
 	XMLName  xml.Name `xml:"epp"`
 	Xmlns    string   `xml:"xmlns,attr"`
 	Response struct {
-		Result struct {
-			Code int    `xml:"code,attr"`
-			Msg  string `xml:"msg"`
-		} `xml:"result"`
+		Results Results `xml:"result"`
 		ResData struct {
 			HostInfo HostInfoResp `xml:"infData"`
 		} `xml:"resData"`
@@ -22,17 +22,14 @@ type APIPollResponse struct {
 	Obj      string   `xml:"obj,attr"`
 	Xmlns    string   `xml:"xmlns,attr"`
 	Response struct {
-		Result Result `xml:"result"`
+		Results Results `xml:"result"`
 		MsgQ PollMessage `xml:"msgQ"`
 		ResData struct {
 			TrnData struct {
 				Name string `xml:"name"`
 			} `xml:"trnData"`
 		} `xml:"resData"`
-		TrID struct {
-			ClTRID string `xml:"clTRID"`
-			SvTRID string `xml:"svTRID"`
-		} `xml:"trID"`
+		TrID Transaction `xml:"trID"`
 	} `xml:"response"`
 }
 
@@ -2,6 +2,7 @@ package epp
 
 import (
 	"encoding/xml"
+	"strconv"
 	"time"
 )
 
@@ -9,15 +10,64 @@ type APIResult struct {
 	XMLName  xml.Name `xml:"epp"`
 	Xmlns    string   `xml:"xmlns,attr"`
 	Response struct {
-		Result Result `xml:"result"`
-		ResData ResData `xml:"resData"`
-		TrID   Transaction `xml:"trID"`
+		Results Results     `xml:"result"`
+		ResData ResData     `xml:"resData"`
+		TrID    Transaction `xml:"trID"`
 	} `xml:"response"`
 }
 
+// Response is the generic envelope of an EPP response, for commands sent as
+// raw XML. The response and extension data are left as raw XML, as their
+// structure depends on the command.
+type Response struct {
+	XMLName   xml.Name     `xml:"epp"`
+	Results   Results      `xml:"response>result"`
+	MsgQ      *PollMessage `xml:"response>msgQ"`
+	ResData   RawXML       `xml:"response>resData"`
+	Extension RawXML       `xml:"response>extension"`
+	TrID      Transaction  `xml:"response>trID"`
+}
+
+// RawXML holds the contents of an element as raw XML.
+type RawXML struct {
+	XML string `xml:",innerxml"`
+}
+
+// Results holds the result elements of a response. Responses to failed
+// commands may contain several of them, the first one being the primary.
+type Results []Result
+
+// Code returns the code of the primary result, or zero if there is none.
+func (r Results) Code() ResultCode {
+	return r.Primary().Code
+}
+
+// Primary returns the first result of the response.
+func (r Results) Primary() Result {
+	if len(r) == 0 {
+		return Result{}
+	}
+
+	return r[0]
+}
+
 type Result struct {
-	Code int `xml:"code,attr"`
-	Msg  string `xml:"msg"`
+	Code      ResultCode    `xml:"code,attr"`
+	Msg       string        `xml:"msg"`
+	Values    []ResultValue `xml:"value"`
+	ExtValues []ExtValue    `xml:"extValue"`
+}
+
+// ResultValue holds the element, as raw XML, that caused a command to fail.
+type ResultValue struct {
+	XML string `xml:",innerxml"`
+}
+
+// ExtValue holds the element that caused a command to fail and the server's
+// explanation of the reason.
+type ExtValue struct {
+	Value  ResultValue `xml:"value"`
+	Reason string      `xml:"reason"`
 }
 
 type ResData struct {
@@ -26,8 +76,8 @@ type ResData struct {
 	ChkData       struct {
 		Cd []ItemCheck `xml:"cd"`
 	} `xml:"chkData"`
-	CreateData  CreateData `xml:"creData"`
-	RenewalData RenewalData `xml:"renData"`
+	CreateData   CreateData   `xml:"creData"`
+	RenewalData  RenewalData  `xml:"renData"`
 	TransferData TransferData `xml:"trnData"`
 }
 
@@ -73,3 +123,118 @@ type Transaction struct {
 	ClTRID string `xml:"clTRID"`
 	SvTRID string `xml:"svTRID"`
 }
+
+// ResultCode is the result code of an EPP response, as defined in RFC 5730.
+type ResultCode int
+
+const (
+	ResultCommandCompleted                    ResultCode = 1000
+	ResultActionPending                       ResultCode = 1001
+	ResultNoMessages                          ResultCode = 1300
+	ResultAckToDequeue                        ResultCode = 1301
+	ResultEndingSession                       ResultCode = 1500
+	ResultUnknownCommand                      ResultCode = 2000
+	ResultCommandSyntaxError                  ResultCode = 2001
+	ResultCommandUseError                     ResultCode = 2002
+	ResultRequiredParameterMissing            ResultCode = 2003
+	ResultParameterValueRangeError            ResultCode = 2004
+	ResultParameterValueSyntaxError           ResultCode = 2005
+	ResultUnimplementedProtocolVersion        ResultCode = 2100
+	ResultUnimplementedCommand                ResultCode = 2101
+	ResultUnimplementedOption                 ResultCode = 2102
+	ResultUnimplementedExtension              ResultCode = 2103
+	ResultBillingFailure                      ResultCode = 2104
+	ResultObjectNotEligibleForRenewal         ResultCode = 2105
+	ResultObjectNotEligibleForTransfer        ResultCode = 2106
+	ResultAuthenticationError                 ResultCode = 2200
+	ResultAuthorizationError                  ResultCode = 2201
+	ResultInvalidAuthorizationInfo            ResultCode = 2202
+	ResultObjectPendingTransfer               ResultCode = 2300
+	ResultObjectNotPendingTransfer            ResultCode = 2301
+	ResultObjectExists                        ResultCode = 2302
+	ResultObjectDoesNotExist                  ResultCode = 2303
+	ResultObjectStatusProhibitsOperation      ResultCode = 2304
+	ResultObjectAssociationProhibitsOperation ResultCode = 2305
+	ResultParameterValuePolicyError           ResultCode = 2306
+	ResultUnimplementedObjectService          ResultCode = 2307
+	ResultDataManagementPolicyViolation       ResultCode = 2308
+	ResultCommandFailed                       ResultCode = 2400
+	ResultCommandFailedServerClosing          ResultCode = 2500
+	ResultAuthenticationErrorServerClosing    ResultCode = 2501
+	ResultSessionLimitExceeded                ResultCode = 2502
+)
+
+var resultMessages = map[ResultCode]string{
+	ResultCommandCompleted:                    "Command completed successfully",
+	ResultActionPending:                       "Command completed successfully; action pending",
+	ResultNoMessages:                          "Command completed successfully; no messages",
+	ResultAckToDequeue:                        "Command completed successfully; ack to dequeue",
+	ResultEndingSession:                       "Command completed successfully; ending session",
+	ResultUnknownCommand:                      "Unknown command",
+	ResultCommandSyntaxError:                  "Command syntax error",
+	ResultCommandUseError:                     "Command use error",
+	ResultRequiredParameterMissing:            "Required parameter missing",
+	ResultParameterValueRangeError:            "Parameter value range error",
+	ResultParameterValueSyntaxError:           "Parameter value syntax error",
+	ResultUnimplementedProtocolVersion:        "Unimplemented protocol version",
+	ResultUnimplementedCommand:                "Unimplemented command",
+	ResultUnimplementedOption:                 "Unimplemented option",
+	ResultUnimplementedExtension:              "Unimplemented extension",
+	ResultBillingFailure:                      "Billing failure",
+	ResultObjectNotEligibleForRenewal:         "Object is not eligible for renewal",
+	ResultObjectNotEligibleForTransfer:        "Object is not eligible for transfer",
+	ResultAuthenticationError:                 "Authentication error",
+	ResultAuthorizationError:                  "Authorization error",
+	ResultInvalidAuthorizationInfo:            "Invalid authorization information",
+	ResultObjectPendingTransfer:               "Object pending transfer",
+	ResultObjectNotPendingTransfer:            "Object not pending transfer",
+	ResultObjectExists:                        "Object exists",
+	ResultObjectDoesNotExist:                  "Object does not exist",
+	ResultObjectStatusProhibitsOperation:      "Object status prohibits operation",
+	ResultObjectAssociationProhibitsOperation: "Object association prohibits operation",
+	ResultParameterValuePolicyError:           "Parameter value policy error",
+	ResultUnimplementedObjectService:          "Unimplemented object service",
+	ResultDataManagementPolicyViolation:       "Data management policy violation",
+	ResultCommandFailed:                       "Command failed",
+	ResultCommandFailedServerClosing:          "Command failed; server closing connection",
+	ResultAuthenticationErrorServerClosing:    "Authentication error; server closing connection",
+	ResultSessionLimitExceeded:                "Session limit exceeded; server closing connection",
+}
+
+// String returns the message RFC 5730 defines for the code.
+func (c ResultCode) String() string {
+	if msg, ok := resultMessages[c]; ok {
+		return msg
+	}
+
+	return "Unknown result code " + strconv.Itoa(int(c))
+}
+
+// IsSuccess tells whether the command was completed, possibly with the
+// action still pending.
+func (c ResultCode) IsSuccess() bool {
+	return c >= 1000 && c < 2000
+}
+
+// IsPending tells whether the command was accepted, but the requested action
+// is still waiting to be completed by the server.
+func (c ResultCode) IsPending() bool {
+	return c == ResultActionPending
+}
+
+// IsRetryable tells whether the command failed for a reason unrelated to the
+// command itself, so that sending it again later may succeed.
+func (c ResultCode) IsRetryable() bool {
+	switch c {
+	case ResultCommandFailed, ResultCommandFailedServerClosing, ResultSessionLimitExceeded:
+		return true
+	}
+
+	return false
+}
+
+// ClosesConnection tells whether the server closes the connection after
+// responding with the code.
+func (c ResultCode) ClosesConnection() bool {
+	return c >= 2500 && c < 2600
+}
@@ -0,0 +1,196 @@
+package epptest
+
+import (
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"strconv"
+	"time"
+)
+
+// registrantRole is the role of contacts that can be registrants of domains.
+const registrantRole = 5
+
+type contact struct {
+	id     string
+	fields contactFields
+	clID   string
+	crID   string
+	crDate time.Time
+	upDate time.Time
+}
+
+func (s *Server) checkContacts(obj *contactObject) *response {
+	data := newCheckData(epp.ContactNamespace)
+	for _, id := range obj.IDs {
+		item := checkItem{ID: &checkName{Name: id}}
+		if _, exists := s.contacts[id]; exists {
+			item.Reason = "Contact exists"
+		} else {
+			item.ID.Avail = 1
+		}
+		data.Items = append(data.Items, item)
+	}
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(data)
+	return resp
+}
+
+// findContact returns a contact of the account. Contacts of other registrars
+// cannot be used, but they can be linked to domains by their ID.
+func (s *Server) findContact(acc *account, id string) (*contact, *commandError) {
+	c, ok := s.contacts[id]
+	if !ok {
+		return nil, valueFailure(epp.ResultObjectDoesNotExist, "contact:id", id, "Contact does not exist")
+	}
+	if c.clID != acc.clID {
+		return nil, valueFailure(epp.ResultAuthorizationError, "contact:id", id, "Contact is sponsored by another registrar")
+	}
+
+	return c, nil
+}
+
+func (s *Server) contactInfo(acc *account, obj *contactObject) (*response, *commandError) {
+	c, err := s.findContact(acc, obj.id())
+	if err != nil {
+		return nil, err
+	}
+
+	info := &contactInfoData{
+		Xmlns:      epp.ContactNamespace,
+		ID:         c.id,
+		Role:       c.fields.Role,
+		Type:       c.fields.Type,
+		Voice:      c.fields.Voice,
+		Email:      c.fields.Email,
+		LegalEmail: c.fields.LegalEmail,
+		ClID:       c.clID,
+		CrID:       c.crID,
+		CrDate:     formatDate(c.crDate),
+		UpDate:     formatDate(c.upDate),
+	}
+
+	postalInfo := c.fields.PostalInfo
+	info.PostalInfo.Type = postalInfo.Type
+	info.PostalInfo.IsFinnish = postalInfo.IsFinnish
+	info.PostalInfo.FirstName = postalInfo.FirstName
+	info.PostalInfo.LastName = postalInfo.LastName
+	info.PostalInfo.Name = postalInfo.Name
+	info.PostalInfo.Org = postalInfo.Org
+	info.PostalInfo.BirthDate = postalInfo.BirthDate
+	info.PostalInfo.Identity = postalInfo.Identity
+	info.PostalInfo.RegisterNumber = postalInfo.RegisterNumber
+	info.PostalInfo.Addr.Street = postalInfo.Addr.Street
+	info.PostalInfo.Addr.City = postalInfo.Addr.City
+	info.PostalInfo.Addr.State = postalInfo.Addr.State
+	info.PostalInfo.Addr.PostalCode = postalInfo.Addr.PostalCode
+	info.PostalInfo.Addr.Country = postalInfo.Addr.Country
+
+	info.Disclose.Data.Flag = c.fields.Disclose.Flag
+	info.Disclose.Data.Email = c.fields.Disclose.Email
+	info.Disclose.Data.Address = c.fields.Disclose.Address
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(info)
+	return resp, nil
+}
+
+// createContact creates a contact with an ID assigned by the registry.
+func (s *Server) createContact(acc *account, obj *contactObject) (*response, *commandError) {
+	if err := validateContact(&obj.contactFields); err != nil {
+		return nil, err
+	}
+
+	s.contactID++
+	now := s.now()
+	c := &contact{
+		id:     "C" + strconv.Itoa(100000+s.contactID),
+		fields: obj.contactFields,
+		clID:   acc.clID,
+		crID:   acc.clID,
+		crDate: now,
+	}
+	s.contacts[c.id] = c
+
+	data := newCreateData(epp.ContactNamespace)
+	data.ID = c.id
+	data.CrDate = formatDate(now)
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(data)
+	return resp, nil
+}
+
+func (s *Server) updateContact(acc *account, obj *contactObject) (*response, *commandError) {
+	c, err := s.findContact(acc, obj.id())
+	if err != nil {
+		return nil, err
+	}
+	if obj.Chg == nil {
+		return newResponse(epp.ResultCommandCompleted), nil
+	}
+	if err = validateContact(obj.Chg); err != nil {
+		return nil, err
+	}
+	if c.fields.Role == registrantRole && obj.Chg.Role != registrantRole && s.isRegistrant(c.id) {
+		return nil, valueFailure(epp.ResultObjectAssociationProhibitsOperation, "contact:id", c.id, "Contact is the registrant of a domain")
+	}
+
+	c.fields = *obj.Chg
+	c.upDate = s.now()
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+func (s *Server) deleteContact(acc *account, obj *contactObject) (*response, *commandError) {
+	c, err := s.findContact(acc, obj.id())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dom := range s.domains {
+		linked := dom.registrant == c.id
+		for _, domContact := range dom.contacts {
+			linked = linked || domContact.ID == c.id
+		}
+		if linked {
+			return nil, valueFailure(epp.ResultObjectAssociationProhibitsOperation, "contact:id", c.id, "Contact is linked to domain "+dom.name)
+		}
+	}
+
+	delete(s.contacts, c.id)
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+func (s *Server) isRegistrant(id string) bool {
+	for _, dom := range s.domains {
+		if dom.registrant == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateContact checks the rules the registry enforces on contacts, which
+// the client validates in more detail before sending them.
+func validateContact(fields *contactFields) *commandError {
+	if fields.Role < 2 || fields.Role > 5 {
+		return valueFailure(epp.ResultParameterValueRangeError, "contact:role", strconv.Itoa(fields.Role), "Role must be 2-5")
+	}
+	if fields.Type < 0 || fields.Type > 7 {
+		return valueFailure(epp.ResultParameterValueRangeError, "contact:type", strconv.Itoa(fields.Type), "Type must be 0-7")
+	}
+	if fields.Role == registrantRole && fields.LegalEmail == "" {
+		return &commandError{code: epp.ResultRequiredParameterMissing, reason: "Registrant requires a legal email"}
+	}
+	if fields.Role != registrantRole && fields.Email == "" {
+		return &commandError{code: epp.ResultRequiredParameterMissing, reason: "Contact requires an email"}
+	}
+	if fields.Type == 0 && (fields.PostalInfo.FirstName == "" || fields.PostalInfo.LastName == "") {
+		return &commandError{code: epp.ResultRequiredParameterMissing, reason: "Private person requires a first and a last name"}
+	}
+	if fields.Type != 0 && fields.PostalInfo.Org == "" {
+		return &commandError{code: epp.ResultRequiredParameterMissing, reason: "Organisation requires a name"}
+	}
+
+	return nil
+}
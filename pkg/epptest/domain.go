@@ -0,0 +1,530 @@
+package epptest
+
+import (
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxValidityYears is the longest a domain can be registered for, also when
+// renewing it.
+const MaxValidityYears = 5
+
+var validDomainName = regexp.MustCompile(`^[a-z0-9][a-z0-9\-]{0,61}[a-z0-9]\.fi$`)
+
+type domain struct {
+	name       string
+	registrant string
+	contacts   []domainContact
+	ns         []string
+	dsData     []dsData
+	clID       string
+	crID       string
+	crDate     time.Time
+	upDate     time.Time
+	exDate     time.Time
+	trDate     time.Time
+
+	transferKey  string
+	ownershipKey string
+
+	locked      bool
+	lockNumbers []string
+	lockKey     string
+}
+
+func (d *domain) clone() *domain {
+	c := *d
+	c.contacts = append([]domainContact(nil), d.contacts...)
+	c.ns = append([]string(nil), d.ns...)
+	c.dsData = append([]dsData(nil), d.dsData...)
+
+	return &c
+}
+
+func (s *Server) checkDomains(obj *domainObject) *response {
+	data := newCheckData(epp.DomainNamespace)
+	for _, name := range obj.Names {
+		item := checkItem{Name: &checkName{Name: name}}
+		switch _, exists := s.domains[name]; {
+		case !validDomainName.MatchString(name):
+			item.Reason = "Invalid domain name"
+		case exists:
+			item.Reason = "Domain is already registered"
+		default:
+			item.Name.Avail = 1
+		}
+		data.Items = append(data.Items, item)
+	}
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(data)
+	return resp
+}
+
+// findDomain returns the domain and, if sponsored is set, fails unless it is
+// sponsored by the account.
+func (s *Server) findDomain(acc *account, name string, sponsored bool) (*domain, *commandError) {
+	dom, ok := s.domains[name]
+	if !ok {
+		return nil, valueFailure(epp.ResultObjectDoesNotExist, "domain:name", name, "Domain is not registered")
+	}
+	if sponsored && dom.clID != acc.clID {
+		return nil, valueFailure(epp.ResultAuthorizationError, "domain:name", name, "Domain is sponsored by another registrar")
+	}
+
+	return dom, nil
+}
+
+func (s *Server) domainInfo(acc *account, obj *domainObject) (*response, *commandError) {
+	dom, err := s.findDomain(acc, obj.name(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &domainInfoData{
+		Xmlns:        epp.DomainNamespace,
+		Name:         dom.name,
+		RegistryLock: boolInt(dom.locked),
+		Registrant:   dom.registrant,
+		ClID:         dom.clID,
+		CrID:         dom.crID,
+		CrDate:       formatDate(dom.crDate),
+		UpDate:       formatDate(dom.upDate),
+		ExDate:       formatDate(dom.exDate),
+		TrDate:       formatDate(dom.trDate),
+	}
+	info.Status.S = "Granted"
+	info.Ns.HostObj = dom.ns
+	for _, contact := range dom.contacts {
+		info.Contacts = append(info.Contacts, domainInfoContact{ID: contact.ID, Type: contact.Type})
+	}
+	// Only the sponsoring registrar sees the transfer key.
+	if dom.clID == acc.clID && dom.transferKey != "" {
+		info.AuthInfo = &domainInfoAuthInfo{Pw: dom.transferKey}
+	}
+	for _, ds := range dom.dsData {
+		info.DsData = append(info.DsData, ds.info())
+	}
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(info)
+	return resp, nil
+}
+
+func (s *Server) createDomain(acc *account, obj *domainObject) (*response, *commandError) {
+	name := obj.name()
+	if !validDomainName.MatchString(name) {
+		return nil, valueFailure(epp.ResultParameterValueSyntaxError, "domain:name", name, "Invalid domain name")
+	}
+	if _, exists := s.domains[name]; exists {
+		return nil, valueFailure(epp.ResultObjectExists, "domain:name", name, "Domain is already registered")
+	}
+	if err := checkPeriod(obj); err != nil {
+		return nil, err
+	}
+	if obj.Registrant == "" {
+		return nil, &commandError{code: epp.ResultRequiredParameterMissing, reason: "Registrant is required"}
+	}
+	if err := s.checkRegistrant(obj.Registrant); err != nil {
+		return nil, err
+	}
+	if err := s.checkDomainContacts(obj.Contacts); err != nil {
+		return nil, err
+	}
+	if err := s.charge(acc, obj.Period.Amount); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	dom := &domain{
+		name:       name,
+		registrant: obj.Registrant,
+		contacts:   obj.Contacts,
+		clID:       acc.clID,
+		crID:       acc.clID,
+		crDate:     now,
+		exDate:     now.AddDate(obj.Period.Amount, 0, 0),
+	}
+	if obj.Ns != nil {
+		dom.ns = obj.Ns.HostObj
+	}
+	s.domains[name] = dom
+
+	data := newCreateData(epp.DomainNamespace)
+	data.Name = name
+	data.CrDate = formatDate(dom.crDate)
+	data.ExDate = formatDate(dom.exDate)
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(data)
+	return resp, nil
+}
+
+func (s *Server) renewDomain(acc *account, obj *domainObject) (*response, *commandError) {
+	dom, err := s.findDomain(acc, obj.name(), true)
+	if err != nil {
+		return nil, err
+	}
+	if obj.CurExpDate != dom.exDate.UTC().Format("2006-01-02") {
+		return nil, valueFailure(epp.ResultParameterValuePolicyError, "domain:curExpDate", obj.CurExpDate, "Current expiration date does not match")
+	}
+	if err = checkPeriod(obj); err != nil {
+		return nil, err
+	}
+
+	exDate := dom.exDate.AddDate(obj.Period.Amount, 0, 0)
+	if exDate.After(s.now().AddDate(MaxValidityYears, 0, 0)) {
+		return nil, &commandError{code: epp.ResultObjectNotEligibleForRenewal, reason: "Domain cannot be valid for more than five years"}
+	}
+	if err = s.charge(acc, obj.Period.Amount); err != nil {
+		return nil, err
+	}
+	dom.exDate = exDate
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(&renewData{Xmlns: epp.DomainNamespace, Name: dom.name, ExDate: formatDate(dom.exDate)})
+	return resp, nil
+}
+
+// transferDomain moves the domain to the registrar knowing its transfer key.
+// The FI registry completes transfers immediately and notifies the losing
+// registrar with a poll message.
+func (s *Server) transferDomain(acc *account, obj *domainObject) (*response, *commandError) {
+	dom, err := s.findDomain(acc, obj.name(), false)
+	if err != nil {
+		return nil, err
+	}
+	if dom.clID == acc.clID {
+		return nil, valueFailure(epp.ResultObjectNotEligibleForTransfer, "domain:name", dom.name, "Domain is already sponsored by the registrar")
+	}
+	if dom.locked {
+		return nil, valueFailure(epp.ResultObjectStatusProhibitsOperation, "domain:name", dom.name, "Domain is protected by registry lock")
+	}
+	if obj.AuthInfo == nil || dom.transferKey == "" || obj.AuthInfo.Pw != dom.transferKey {
+		return nil, &commandError{code: epp.ResultInvalidAuthorizationInfo, reason: "Invalid transfer key"}
+	}
+
+	losing := dom.clID
+	now := s.now()
+	dom.clID = acc.clID
+	dom.trDate = now
+	dom.transferKey = ""
+	if obj.Ns != nil && len(obj.Ns.HostObj) > 0 {
+		dom.ns = obj.Ns.HostObj
+	}
+	if losingAcc, ok := s.accounts[losing]; ok {
+		s.enqueue(losingAcc, "Domain transferred", dom.name)
+	}
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(&transferData{
+		Xmlns:    objNamespace,
+		Name:     dom.name,
+		TrStatus: "Transferred",
+		ReID:     acc.clID,
+		ReDate:   formatDate(now),
+		AcID:     losing,
+	})
+	return resp, nil
+}
+
+func (s *Server) deleteDomain(acc *account, obj *domainObject) (*response, *commandError) {
+	dom, err := s.findDomain(acc, obj.name(), true)
+	if err != nil {
+		return nil, err
+	}
+	if dom.locked {
+		return nil, valueFailure(epp.ResultObjectStatusProhibitsOperation, "domain:name", dom.name, "Domain is protected by registry lock")
+	}
+	for hostname := range s.hosts {
+		if strings.HasSuffix(hostname, "."+dom.name) {
+			return nil, valueFailure(epp.ResultObjectAssociationProhibitsOperation, "host:name", hostname, "Domain has subordinate hosts")
+		}
+	}
+
+	delete(s.domains, dom.name)
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+// updateDomain applies all changes of the update, or none of them if any of
+// them fails.
+func (s *Server) updateDomain(acc *account, obj *domainObject, secDNS *secDNSUpdate) (*response, *commandError) {
+	dom, err := s.findDomain(acc, obj.name(), true)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Chg != nil && obj.Chg.RegistryLock != nil {
+		return s.updateRegistryLock(dom, obj.Chg)
+	}
+	if dom.locked {
+		return nil, valueFailure(epp.ResultObjectStatusProhibitsOperation, "domain:name", dom.name, "Domain is protected by registry lock")
+	}
+
+	updated := dom.clone()
+	if obj.Add != nil {
+		if err = s.addToDomain(updated, obj.Add); err != nil {
+			return nil, err
+		}
+	}
+	if obj.Rem != nil {
+		if err = removeFromDomain(updated, obj.Rem); err != nil {
+			return nil, err
+		}
+	}
+	if obj.Chg != nil {
+		if err = s.changeDomain(updated, obj.Chg); err != nil {
+			return nil, err
+		}
+	}
+	if secDNS != nil {
+		if err = updateDSData(updated, secDNS); err != nil {
+			return nil, err
+		}
+	}
+
+	updated.upDate = s.now()
+	s.domains[dom.name] = updated
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+func (s *Server) addToDomain(dom *domain, add *domainAddRem) *commandError {
+	if add.Status != nil {
+		return &commandError{code: epp.ResultUnimplementedOption, reason: "Domain statuses are managed by the registry"}
+	}
+	if add.Ns == nil {
+		return nil
+	}
+
+	for _, ns := range add.Ns.HostObj {
+		if contains(dom.ns, ns) {
+			return valueFailure(epp.ResultObjectExists, "domain:hostObj", ns, "Name server is already set")
+		}
+		dom.ns = append(dom.ns, ns)
+	}
+
+	return nil
+}
+
+func removeFromDomain(dom *domain, rem *domainAddRem) *commandError {
+	if rem.Status != nil {
+		return &commandError{code: epp.ResultUnimplementedOption, reason: "Domain statuses are managed by the registry"}
+	}
+	if rem.AuthInfo != nil && rem.AuthInfo.Pw != "" {
+		dom.transferKey = ""
+	}
+	if rem.Ns == nil {
+		return nil
+	}
+
+	for _, ns := range rem.Ns.HostObj {
+		if !contains(dom.ns, ns) {
+			return valueFailure(epp.ResultObjectDoesNotExist, "domain:hostObj", ns, "Name server is not set")
+		}
+		dom.ns = remove(dom.ns, ns)
+	}
+
+	return nil
+}
+
+func (s *Server) changeDomain(dom *domain, chg *domainChange) *commandError {
+	if err := s.checkDomainContacts(chg.Contacts); err != nil {
+		return err
+	}
+	for _, contact := range chg.Contacts {
+		replaced := false
+		for i := range dom.contacts {
+			if dom.contacts[i].Type == contact.Type {
+				dom.contacts[i].ID = contact.ID
+				replaced = true
+			}
+		}
+		if !replaced {
+			dom.contacts = append(dom.contacts, contact)
+		}
+	}
+
+	var authInfo domainAuthInfo
+	if chg.AuthInfo != nil {
+		authInfo = *chg.AuthInfo
+	}
+
+	if authInfo.Pw != "" {
+		if len(authInfo.Pw) < 8 || len(authInfo.Pw) > 64 {
+			return &commandError{code: epp.ResultParameterValuePolicyError, reason: "Transfer key must be 8-64 characters long"}
+		}
+		dom.transferKey = authInfo.Pw
+	}
+
+	// Changing the registrant takes the key the registry sent to the current
+	// registrant, after the registrar requested one with the value "new".
+	switch {
+	case authInfo.PwRegistrantTransfer == "new" && chg.Registrant == "":
+		dom.ownershipKey = randomKey(8)
+	case chg.Registrant != "":
+		if dom.ownershipKey == "" || authInfo.PwRegistrantTransfer != dom.ownershipKey {
+			return &commandError{code: epp.ResultInvalidAuthorizationInfo, reason: "Invalid ownership change key"}
+		}
+		if err := s.checkRegistrant(chg.Registrant); err != nil {
+			return err
+		}
+		dom.registrant = chg.Registrant
+		dom.ownershipKey = ""
+	}
+
+	return nil
+}
+
+func (s *Server) updateRegistryLock(dom *domain, chg *domainChange) (*response, *commandError) {
+	lock := chg.RegistryLock
+
+	switch lock.Type {
+	case "activate":
+		if dom.locked {
+			return nil, valueFailure(epp.ResultObjectStatusProhibitsOperation, "domain:name", dom.name, "Registry lock is already active")
+		}
+		if len(lock.SmsNumbers) < 2 || len(lock.SmsNumbers) > 3 {
+			return nil, &commandError{code: epp.ResultParameterValuePolicyError, reason: "Registry lock requires 2-3 SMS numbers"}
+		}
+		dom.locked = true
+		dom.lockNumbers = lock.SmsNumbers
+		dom.lockKey = ""
+	case "requestkey":
+		if !dom.locked {
+			return nil, valueFailure(epp.ResultObjectStatusProhibitsOperation, "domain:name", dom.name, "Registry lock is not active")
+		}
+		if lock.NumberToSend < 1 || lock.NumberToSend > len(dom.lockNumbers) {
+			return nil, &commandError{code: epp.ResultParameterValueRangeError, reason: "No such SMS number"}
+		}
+		dom.lockKey = randomKey(4)
+	case "deactivate":
+		if !dom.locked {
+			return nil, valueFailure(epp.ResultObjectStatusProhibitsOperation, "domain:name", dom.name, "Registry lock is not active")
+		}
+		if dom.lockKey == "" || lock.AuthKey != dom.lockKey {
+			return nil, &commandError{code: epp.ResultInvalidAuthorizationInfo, reason: "Invalid registry lock key"}
+		}
+		dom.locked = false
+		dom.lockNumbers = nil
+		dom.lockKey = ""
+	default:
+		return nil, &commandError{code: epp.ResultParameterValueSyntaxError, reason: "Unknown registry lock operation " + lock.Type}
+	}
+
+	dom.upDate = s.now()
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+func updateDSData(dom *domain, update *secDNSUpdate) *commandError {
+	if update.Rem.All {
+		dom.dsData = nil
+	}
+	for _, ds := range update.Rem.DsData {
+		i := indexDSData(dom.dsData, ds)
+		if i < 0 {
+			return &commandError{code: epp.ResultObjectDoesNotExist, reason: "DS record with key tag " + strconv.Itoa(ds.KeyTag) + " is not set"}
+		}
+		dom.dsData = append(dom.dsData[:i:i], dom.dsData[i+1:]...)
+	}
+	for _, ds := range update.Add.DsData {
+		if indexDSData(dom.dsData, ds) >= 0 {
+			return &commandError{code: epp.ResultObjectExists, reason: "DS record with key tag " + strconv.Itoa(ds.KeyTag) + " is already set"}
+		}
+		dom.dsData = append(dom.dsData, ds)
+	}
+
+	return nil
+}
+
+// indexDSData finds a DS record by its digest, as key data is optional.
+func indexDSData(records []dsData, ds dsData) int {
+	for i, record := range records {
+		if record.KeyTag == ds.KeyTag && record.Alg == ds.Alg && record.DigestType == ds.DigestType && strings.EqualFold(record.Digest, ds.Digest) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (ds dsData) info() domainInfoDSData {
+	var info domainInfoDSData
+	info.KeyTag = ds.KeyTag
+	info.Alg = ds.Alg
+	info.DigestType = ds.DigestType
+	info.Digest = ds.Digest
+	info.KeyData.Flags = ds.KeyData.Flags
+	info.KeyData.Protocol = ds.KeyData.Protocol
+	info.KeyData.Alg = ds.KeyData.Alg
+	info.KeyData.PubKey = ds.KeyData.PubKey
+
+	return info
+}
+
+func checkPeriod(obj *domainObject) *commandError {
+	if obj.Period.Unit != "y" || obj.Period.Amount < 1 || obj.Period.Amount > MaxValidityYears {
+		return valueFailure(epp.ResultParameterValueRangeError, "domain:period", strconv.Itoa(obj.Period.Amount), "Period must be 1-5 years")
+	}
+
+	return nil
+}
+
+// checkRegistrant fails unless id is a contact with the registrant role.
+func (s *Server) checkRegistrant(id string) *commandError {
+	registrant, ok := s.contacts[id]
+	if !ok {
+		return valueFailure(epp.ResultObjectDoesNotExist, "domain:registrant", id, "Contact does not exist")
+	}
+	if registrant.fields.Role != registrantRole {
+		return valueFailure(epp.ResultParameterValuePolicyError, "domain:registrant", id, "Contact is not a registrant")
+	}
+
+	return nil
+}
+
+func (s *Server) checkDomainContacts(contacts []domainContact) *commandError {
+	for _, contact := range contacts {
+		if contact.Type != "admin" && contact.Type != "tech" {
+			return valueFailure(epp.ResultParameterValueSyntaxError, "domain:contact", contact.ID, "Contact type must be admin or tech")
+		}
+		if _, ok := s.contacts[contact.ID]; !ok {
+			return valueFailure(epp.ResultObjectDoesNotExist, "domain:contact", contact.ID, "Contact does not exist")
+		}
+	}
+
+	return nil
+}
+
+// charge deducts the price of a domain for years from the balance.
+func (s *Server) charge(acc *account, years int) *commandError {
+	price := s.domainPrice * years
+	if acc.balance < price {
+		return &commandError{code: epp.ResultBillingFailure, reason: "Insufficient balance"}
+	}
+
+	acc.balance -= price
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remove(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,208 @@
+package epptest
+
+import (
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var validHostname = regexp.MustCompile(`^([a-z0-9]([a-z0-9\-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+type host struct {
+	name   string
+	addrs  []hostAddress
+	clID   string
+	crID   string
+	crDate time.Time
+	upDate time.Time
+}
+
+func (s *Server) checkHosts(obj *hostObject) *response {
+	data := newCheckData(epp.HostNamespace)
+	for _, name := range obj.Names {
+		item := checkItem{Name: &checkName{Name: name}}
+		switch _, exists := s.hosts[name]; {
+		case !validHostname.MatchString(name):
+			item.Reason = "Invalid host name"
+		case exists:
+			item.Reason = "Host exists"
+		default:
+			item.Name.Avail = 1
+		}
+		data.Items = append(data.Items, item)
+	}
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(data)
+	return resp
+}
+
+func (s *Server) findHost(acc *account, name string, sponsored bool) (*host, *commandError) {
+	h, ok := s.hosts[name]
+	if !ok {
+		return nil, valueFailure(epp.ResultObjectDoesNotExist, "host:name", name, "Host does not exist")
+	}
+	if sponsored && h.clID != acc.clID {
+		return nil, valueFailure(epp.ResultAuthorizationError, "host:name", name, "Host is sponsored by another registrar")
+	}
+
+	return h, nil
+}
+
+func (s *Server) hostInfo(obj *hostObject) (*response, *commandError) {
+	h, err := s.findHost(nil, obj.name(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &hostInfoData{
+		Xmlns:  epp.HostNamespace,
+		Name:   h.name,
+		ClID:   h.clID,
+		CrID:   h.crID,
+		CrDate: formatDate(h.crDate),
+		UpDate: formatDate(h.upDate),
+	}
+	for _, addr := range h.addrs {
+		info.Addrs = append(info.Addrs, hostInfoAddress{IP: addr.IP, Family: addr.Family})
+	}
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(info)
+	return resp, nil
+}
+
+// createHost creates a name server. Hosts under .fi must belong to a domain
+// of the registrar and have IP addresses, as the registry serves their glue
+// records.
+func (s *Server) createHost(acc *account, obj *hostObject) (*response, *commandError) {
+	name := obj.name()
+	if !validHostname.MatchString(name) {
+		return nil, valueFailure(epp.ResultParameterValueSyntaxError, "host:name", name, "Invalid host name")
+	}
+	if _, exists := s.hosts[name]; exists {
+		return nil, valueFailure(epp.ResultObjectExists, "host:name", name, "Host exists")
+	}
+	if err := validateAddresses(obj.Addrs); err != nil {
+		return nil, err
+	}
+
+	if superordinate, ok := superordinateDomain(name); ok {
+		if _, err := s.findDomain(acc, superordinate, true); err != nil {
+			return nil, err
+		}
+		if len(obj.Addrs) == 0 {
+			return nil, valueFailure(epp.ResultRequiredParameterMissing, "host:name", name, "Host under .fi requires IP addresses")
+		}
+	}
+
+	now := s.now()
+	s.hosts[name] = &host{
+		name:   name,
+		addrs:  obj.Addrs,
+		clID:   acc.clID,
+		crID:   acc.clID,
+		crDate: now,
+	}
+
+	data := newCreateData(epp.HostNamespace)
+	data.Name = name
+	data.CrDate = formatDate(now)
+
+	resp := newResponse(epp.ResultCommandCompleted)
+	resp.addData(data)
+	return resp, nil
+}
+
+func (s *Server) updateHost(acc *account, obj *hostObject) (*response, *commandError) {
+	h, err := s.findHost(acc, obj.name(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := append([]hostAddress(nil), h.addrs...)
+	if obj.Rem != nil {
+		for _, addr := range obj.Rem.Addrs {
+			i := indexAddress(addrs, addr)
+			if i < 0 {
+				return nil, valueFailure(epp.ResultObjectDoesNotExist, "host:addr", addr.IP, "Address is not set")
+			}
+			addrs = append(addrs[:i:i], addrs[i+1:]...)
+		}
+	}
+	if obj.Add != nil {
+		if err = validateAddresses(obj.Add.Addrs); err != nil {
+			return nil, err
+		}
+		for _, addr := range obj.Add.Addrs {
+			if indexAddress(addrs, addr) >= 0 {
+				return nil, valueFailure(epp.ResultObjectExists, "host:addr", addr.IP, "Address is already set")
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	if _, subordinate := superordinateDomain(h.name); subordinate && len(addrs) == 0 {
+		return nil, valueFailure(epp.ResultParameterValuePolicyError, "host:name", h.name, "Host under .fi requires IP addresses")
+	}
+
+	h.addrs = addrs
+	h.upDate = s.now()
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+func (s *Server) deleteHost(acc *account, obj *hostObject) (*response, *commandError) {
+	h, err := s.findHost(acc, obj.name(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dom := range s.domains {
+		if contains(dom.ns, h.name) {
+			return nil, valueFailure(epp.ResultObjectAssociationProhibitsOperation, "host:name", h.name, "Host is a name server of domain "+dom.name)
+		}
+	}
+
+	delete(s.hosts, h.name)
+	return newResponse(epp.ResultCommandCompleted), nil
+}
+
+// superordinateDomain returns the .fi domain a host name is under.
+func superordinateDomain(hostname string) (string, bool) {
+	if !strings.HasSuffix(hostname, ".fi") {
+		return "", false
+	}
+
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 3 {
+		return "", false
+	}
+
+	return strings.Join(labels[len(labels)-2:], "."), true
+}
+
+func validateAddresses(addrs []hostAddress) *commandError {
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr.IP)
+		family := "v6"
+		if ip != nil && ip.To4() != nil {
+			family = "v4"
+		}
+		if ip == nil || addr.Family != family {
+			return valueFailure(epp.ResultParameterValueSyntaxError, "host:addr", addr.IP, "Invalid IP address")
+		}
+	}
+
+	return nil
+}
+
+func indexAddress(addrs []hostAddress, addr hostAddress) int {
+	for i, a := range addrs {
+		if net.ParseIP(a.IP).Equal(net.ParseIP(addr.IP)) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,228 @@
+package epptest
+
+import (
+	"encoding/xml"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+)
+
+// The request types match elements by their local names, as the objects of a
+// command are told apart by the namespace of their element in object.
+
+type request struct {
+	XMLName xml.Name  `xml:"epp"`
+	Hello   *struct{} `xml:"hello"`
+	Command *command  `xml:"command"`
+}
+
+type command struct {
+	Login     *loginCommand  `xml:"login"`
+	Logout    *struct{}      `xml:"logout"`
+	Check     *objectCommand `xml:"check"`
+	Info      *objectCommand `xml:"info"`
+	Create    *objectCommand `xml:"create"`
+	Update    *objectCommand `xml:"update"`
+	Delete    *objectCommand `xml:"delete"`
+	Renew     *objectCommand `xml:"renew"`
+	Transfer  *objectCommand `xml:"transfer"`
+	Poll      *pollCommand   `xml:"poll"`
+	Extension *struct {
+		SecDNSUpdate *secDNSUpdate `xml:"urn:ietf:params:xml:ns:secDNS-1.1 update"`
+	} `xml:"extension"`
+	ClTRID string `xml:"clTRID"`
+}
+
+type loginCommand struct {
+	ClID  string `xml:"clID"`
+	Pw    string `xml:"pw"`
+	NewPW string `xml:"newPW"`
+}
+
+type pollCommand struct {
+	Op    string `xml:"op,attr"`
+	MsgID string `xml:"msgID,attr"`
+}
+
+// objectCommand holds the object element of a command, e.g. domain:check
+// within check.
+type objectCommand struct {
+	Op      string   `xml:"op,attr"`
+	Objects []object `xml:",any"`
+}
+
+// object decodes the element into the type of its namespace.
+type object struct {
+	Name    xml.Name
+	Domain  *domainObject
+	Contact *contactObject
+	Host    *hostObject
+}
+
+func (o *object) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	o.Name = start.Name
+
+	switch start.Name.Space {
+	case epp.DomainNamespace:
+		o.Domain = &domainObject{}
+		return d.DecodeElement(o.Domain, &start)
+	case epp.ContactNamespace:
+		o.Contact = &contactObject{}
+		return d.DecodeElement(o.Contact, &start)
+	case epp.HostNamespace:
+		o.Host = &hostObject{}
+		return d.DecodeElement(o.Host, &start)
+	}
+
+	return d.Skip()
+}
+
+// domainObject holds the elements of all domain commands.
+type domainObject struct {
+	Names  []string `xml:"name"`
+	Period struct {
+		Amount int    `xml:",chardata"`
+		Unit   string `xml:"unit,attr"`
+	} `xml:"period"`
+	CurExpDate string          `xml:"curExpDate"`
+	Ns         *nameservers    `xml:"ns"`
+	Registrant string          `xml:"registrant"`
+	Contacts   []domainContact `xml:"contact"`
+	AuthInfo   *domainAuthInfo `xml:"authInfo"`
+	Add        *domainAddRem   `xml:"add"`
+	Rem        *domainAddRem   `xml:"rem"`
+	Chg        *domainChange   `xml:"chg"`
+}
+
+func (o *domainObject) name() string {
+	if len(o.Names) == 0 {
+		return ""
+	}
+
+	return o.Names[0]
+}
+
+type nameservers struct {
+	HostObj []string `xml:"hostObj"`
+}
+
+type domainContact struct {
+	ID   string `xml:",chardata"`
+	Type string `xml:"type,attr"`
+}
+
+type domainAuthInfo struct {
+	Pw                   string `xml:"pw"`
+	PwRegistrantTransfer string `xml:"pwregistranttransfer"`
+}
+
+type domainAddRem struct {
+	Status   *struct{}       `xml:"status"`
+	Ns       *nameservers    `xml:"ns"`
+	AuthInfo *domainAuthInfo `xml:"authInfo"`
+}
+
+type domainChange struct {
+	Registrant   string          `xml:"registrant"`
+	Contacts     []domainContact `xml:"contact"`
+	AuthInfo     *domainAuthInfo `xml:"authInfo"`
+	RegistryLock *struct {
+		Type         string   `xml:"type,attr"`
+		SmsNumbers   []string `xml:"smsnumber"`
+		NumberToSend int      `xml:"numbertosend"`
+		AuthKey      string   `xml:"authkey"`
+	} `xml:"registrylock"`
+}
+
+type secDNSUpdate struct {
+	Rem struct {
+		All    bool     `xml:"all"`
+		DsData []dsData `xml:"dsData"`
+	} `xml:"rem"`
+	Add struct {
+		DsData []dsData `xml:"dsData"`
+	} `xml:"add"`
+}
+
+type dsData struct {
+	KeyTag     int    `xml:"keyTag"`
+	Alg        int    `xml:"alg"`
+	DigestType int    `xml:"digestType"`
+	Digest     string `xml:"digest"`
+	KeyData    struct {
+		Flags    int    `xml:"flags"`
+		Protocol int    `xml:"protocol"`
+		Alg      int    `xml:"alg"`
+		PubKey   string `xml:"pubKey"`
+	} `xml:"keyData"`
+}
+
+// contactObject holds the elements of all contact commands. Created contacts
+// get their ID from the registry.
+type contactObject struct {
+	IDs []string `xml:"id"`
+	contactFields
+	Chg *contactFields `xml:"chg"`
+}
+
+type contactFields struct {
+	Role       int `xml:"role"`
+	Type       int `xml:"type"`
+	PostalInfo struct {
+		Type           string `xml:"type,attr"`
+		IsFinnish      int    `xml:"isfinnish"`
+		FirstName      string `xml:"firstname"`
+		LastName       string `xml:"lastname"`
+		Name           string `xml:"name"`
+		Org            string `xml:"org"`
+		BirthDate      string `xml:"birthDate"`
+		Identity       string `xml:"identity"`
+		RegisterNumber string `xml:"registernumber"`
+		Addr           struct {
+			Street     []string `xml:"street"`
+			City       string   `xml:"city"`
+			State      string   `xml:"sp"`
+			PostalCode string   `xml:"pc"`
+			Country    string   `xml:"cc"`
+		} `xml:"addr"`
+	} `xml:"postalInfo"`
+	Voice      string `xml:"voice"`
+	Email      string `xml:"email"`
+	LegalEmail string `xml:"legalemail"`
+	Disclose   struct {
+		Flag    int `xml:"flag,attr"`
+		Email   int `xml:"email"`
+		Address int `xml:"address"`
+	} `xml:"disclose"`
+}
+
+func (o *contactObject) id() string {
+	if len(o.IDs) == 0 {
+		return ""
+	}
+
+	return o.IDs[0]
+}
+
+// hostObject holds the elements of all host commands.
+type hostObject struct {
+	Names []string      `xml:"name"`
+	Addrs []hostAddress `xml:"addr"`
+	Add   *struct {
+		Addrs []hostAddress `xml:"addr"`
+	} `xml:"add"`
+	Rem *struct {
+		Addrs []hostAddress `xml:"addr"`
+	} `xml:"rem"`
+}
+
+type hostAddress struct {
+	IP     string `xml:",chardata"`
+	Family string `xml:"ip,attr"`
+}
+
+func (o *hostObject) name() string {
+	if len(o.Names) == 0 {
+		return ""
+	}
+
+	return o.Names[0]
+}
@@ -0,0 +1,302 @@
+package epptest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFormat is the format of the dates in the registry's responses.
+const dateFormat = "2006-01-02T15:04:05.000"
+
+const objNamespace = "urn:ietf:params:xml:ns:obj-1.0"
+
+type response struct {
+	XMLName  xml.Name `xml:"epp"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Response struct {
+		Results []result `xml:"result"`
+		MsgQ    *msgQ    `xml:"msgQ"`
+		ResData *resData `xml:"resData"`
+		TrID    struct {
+			ClTRID string `xml:"clTRID,omitempty"`
+			SvTRID string `xml:"svTRID"`
+		} `xml:"trID"`
+	} `xml:"response"`
+}
+
+type result struct {
+	Code      epp.ResultCode `xml:"code,attr"`
+	Msg       string         `xml:"msg"`
+	ExtValues []extValue     `xml:"extValue"`
+}
+
+type extValue struct {
+	Value struct {
+		XML string `xml:",innerxml"`
+	} `xml:"value"`
+	Reason string `xml:"reason"`
+}
+
+type msgQ struct {
+	Count int    `xml:"count,attr"`
+	ID    string `xml:"id,attr"`
+	QDate string `xml:"qDate,omitempty"`
+	Msg   string `xml:"msg,omitempty"`
+}
+
+// resData holds one of the data types below, which name their element.
+type resData struct {
+	Data []any
+}
+
+// checkData and createData are shared by the objects, declaring the
+// namespace of the object as the default namespace of their element.
+type checkData struct {
+	XMLName xml.Name
+	Items   []checkItem `xml:"cd"`
+}
+
+type checkItem struct {
+	Name   *checkName `xml:"name,omitempty"`
+	ID     *checkName `xml:"id,omitempty"`
+	Reason string     `xml:"reason,omitempty"`
+}
+
+type checkName struct {
+	Name  string `xml:",chardata"`
+	Avail int    `xml:"avail,attr"`
+}
+
+type balanceData struct {
+	XMLName xml.Name `xml:"balanceamount"`
+	Amount  int      `xml:",chardata"`
+}
+
+type timestampData struct {
+	XMLName xml.Name `xml:"timestamp"`
+	Date    string   `xml:",chardata"`
+}
+
+type createData struct {
+	XMLName xml.Name
+	ID      string `xml:"id,omitempty"`
+	Name    string `xml:"name,omitempty"`
+	CrDate  string `xml:"crDate"`
+	ExDate  string `xml:"exDate,omitempty"`
+}
+
+type renewData struct {
+	XMLName xml.Name `xml:"domain:renData"`
+	Xmlns   string   `xml:"xmlns:domain,attr"`
+	Name    string   `xml:"domain:name"`
+	ExDate  string   `xml:"domain:exDate"`
+}
+
+type transferData struct {
+	XMLName  xml.Name `xml:"obj:trnData"`
+	Xmlns    string   `xml:"xmlns:obj,attr"`
+	Name     string   `xml:"obj:name"`
+	TrStatus string   `xml:"obj:trStatus,omitempty"`
+	ReID     string   `xml:"obj:reID,omitempty"`
+	ReDate   string   `xml:"obj:reDate,omitempty"`
+	AcID     string   `xml:"obj:acID,omitempty"`
+}
+
+type domainInfoData struct {
+	XMLName       xml.Name `xml:"domain:infData"`
+	Xmlns         string   `xml:"xmlns:domain,attr"`
+	Name          string   `xml:"domain:name"`
+	RegistryLock  int      `xml:"domain:registrylock"`
+	AutoRenew     int      `xml:"domain:autorenew"`
+	AutoRenewDate string   `xml:"domain:autorenewDate,omitempty"`
+	Status        struct {
+		S string `xml:"s,attr"`
+	} `xml:"domain:status"`
+	Registrant string              `xml:"domain:registrant"`
+	Contacts   []domainInfoContact `xml:"domain:contact"`
+	Ns         struct {
+		HostObj []string `xml:"domain:hostObj"`
+	} `xml:"domain:ns"`
+	ClID     string              `xml:"domain:clID"`
+	CrID     string              `xml:"domain:crID"`
+	CrDate   string              `xml:"domain:crDate"`
+	UpDate   string              `xml:"domain:upDate,omitempty"`
+	ExDate   string              `xml:"domain:exDate"`
+	TrDate   string              `xml:"domain:trDate,omitempty"`
+	AuthInfo *domainInfoAuthInfo `xml:"domain:authInfo"`
+	DsData   []domainInfoDSData  `xml:"domain:dsData"`
+}
+
+type domainInfoContact struct {
+	ID   string `xml:",chardata"`
+	Type string `xml:"type,attr"`
+}
+
+type domainInfoAuthInfo struct {
+	Pw                   string `xml:"domain:pw,omitempty"`
+	PwRegistrantTransfer string `xml:"domain:pwregistranttransfer,omitempty"`
+}
+
+type domainInfoDSData struct {
+	KeyTag     int    `xml:"domain:keyTag"`
+	Alg        int    `xml:"domain:alg"`
+	DigestType int    `xml:"domain:digestType"`
+	Digest     string `xml:"domain:digest"`
+	KeyData    struct {
+		Flags    int    `xml:"domain:flags"`
+		Protocol int    `xml:"domain:protocol"`
+		Alg      int    `xml:"domain:alg"`
+		PubKey   string `xml:"domain:pubKey"`
+	} `xml:"domain:keyData"`
+}
+
+type contactInfoData struct {
+	XMLName    xml.Name `xml:"contact:infData"`
+	Xmlns      string   `xml:"xmlns:contact,attr"`
+	ID         string   `xml:"contact:id"`
+	Role       int      `xml:"contact:role"`
+	Type       int      `xml:"contact:type"`
+	PostalInfo struct {
+		Type           string `xml:"type,attr"`
+		IsFinnish      int    `xml:"contact:isFinnish"`
+		FirstName      string `xml:"contact:firstname,omitempty"`
+		LastName       string `xml:"contact:lastname,omitempty"`
+		Name           string `xml:"contact:name,omitempty"`
+		Org            string `xml:"contact:org,omitempty"`
+		BirthDate      string `xml:"contact:birthDate,omitempty"`
+		Identity       string `xml:"contact:identity,omitempty"`
+		RegisterNumber string `xml:"contact:registernumber,omitempty"`
+		Addr           struct {
+			Street     []string `xml:"contact:street"`
+			City       string   `xml:"contact:city"`
+			State      string   `xml:"contact:sp,omitempty"`
+			PostalCode string   `xml:"contact:pc"`
+			Country    string   `xml:"contact:cc"`
+		} `xml:"contact:addr"`
+	} `xml:"contact:postalInfo"`
+	Voice      string `xml:"contact:voice"`
+	Email      string `xml:"contact:email"`
+	LegalEmail string `xml:"contact:legalemail"`
+	ClID       string `xml:"contact:clID"`
+	CrID       string `xml:"contact:crID"`
+	CrDate     string `xml:"contact:crDate"`
+	UpDate     string `xml:"contact:upDate,omitempty"`
+	Disclose   struct {
+		Data struct {
+			Flag    int `xml:"flag,attr"`
+			Email   int `xml:"contact:email"`
+			Address int `xml:"contact:address"`
+		} `xml:"contact:infDataDisclose"`
+	} `xml:"contact:disclose"`
+}
+
+type hostInfoData struct {
+	XMLName xml.Name          `xml:"host:infData"`
+	Xmlns   string            `xml:"xmlns:host,attr"`
+	Name    string            `xml:"host:name"`
+	Addrs   []hostInfoAddress `xml:"host:addr"`
+	ClID    string            `xml:"host:clID"`
+	CrID    string            `xml:"host:crID"`
+	CrDate  string            `xml:"host:crDate"`
+	UpDate  string            `xml:"host:upDate,omitempty"`
+}
+
+type hostInfoAddress struct {
+	IP     string `xml:",chardata"`
+	Family string `xml:"ip,attr"`
+}
+
+// commandError is a failed command, reported with its result code and, if
+// the failure was caused by a value of the command, the value and a reason.
+type commandError struct {
+	code   epp.ResultCode
+	value  string
+	reason string
+}
+
+func failure(code epp.ResultCode) *commandError {
+	return &commandError{code: code}
+}
+
+// valueFailure reports the element with text as the value that caused the
+// command to fail.
+func valueFailure(code epp.ResultCode, element, text, reason string) *commandError {
+	return &commandError{code: code, value: rawElement(element, text), reason: reason}
+}
+
+// rawElement returns the XML of a prefixed element, declaring its namespace.
+func rawElement(element, text string) string {
+	var raw bytes.Buffer
+	raw.WriteString("<" + element)
+	if prefix, _, ok := strings.Cut(element, ":"); ok {
+		raw.WriteString(" xmlns:" + prefix + `="` + prefixNamespaces[prefix] + `"`)
+	}
+	raw.WriteString(">")
+	_ = xml.EscapeText(&raw, []byte(text))
+	raw.WriteString("</" + element + ">")
+
+	return raw.String()
+}
+
+var prefixNamespaces = map[string]string{
+	"domain":  epp.DomainNamespace,
+	"contact": epp.ContactNamespace,
+	"host":    epp.HostNamespace,
+}
+
+func newResponse(code epp.ResultCode) *response {
+	resp := &response{Xmlns: epp.EPPNamespace}
+	resp.Response.Results = []result{{Code: code, Msg: code.String()}}
+
+	return resp
+}
+
+func errorResponse(err *commandError) *response {
+	resp := newResponse(err.code)
+	if err.reason != "" {
+		value := extValue{Reason: err.reason}
+		value.Value.XML = err.value
+		resp.Response.Results[0].ExtValues = []extValue{value}
+	}
+
+	return resp
+}
+
+func (r *response) addData(data any) {
+	if r.Response.ResData == nil {
+		r.Response.ResData = &resData{}
+	}
+	r.Response.ResData.Data = append(r.Response.ResData.Data, data)
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.UTC().Format(dateFormat)
+}
+
+func newCheckData(namespace string) *checkData {
+	return &checkData{XMLName: xml.Name{Space: namespace, Local: "chkData"}}
+}
+
+func newCreateData(namespace string) *createData {
+	return &createData{XMLName: xml.Name{Space: namespace, Local: "creData"}}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func svTRID(id int) string {
+	return "SIM-" + strconv.Itoa(id)
+}
@@ -0,0 +1,278 @@
+// Package epptest implements an in-memory simulator of the FI registry's EPP
+// server for integration tests and demos. Unlike replaying canned responses,
+// the simulator keeps state: contacts, domains and hosts created through it
+// can be read, updated, transferred and deleted, the accounts of registrars
+// are charged for registrations and renewals, and commands violating the
+// registry's rules fail with the result codes the registry would return.
+//
+// The server is connected to a client in the same process with
+// registry.PipeTransport:
+//
+//	srv, _ := epptest.New(epptest.WithAccount("registrar", "Password1!", 1000))
+//	client, _ := registry.New(
+//		registry.WithCredentials("registrar", "Password1!"),
+//		registry.WithTransport(&registry.PipeTransport{Serve: srv.Serve}),
+//	)
+//
+// For demos with other EPP clients it can also serve connections accepted
+// from a listener, e.g. a tls.Listener, with ServeListener.
+package epptest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/ajmyyra/go-epp-fi/pkg/registry"
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultDomainPrice is the price of registering or renewing a domain for a
+// year, deducted from the balance of the registrar's account.
+const DefaultDomainPrice = 10
+
+const serverID = "FI EPP Registry Simulator"
+
+type Option func(*Server) error
+
+// WithAccount adds a registrar account that can log in with clID and
+// password, with balance to pay for registrations and renewals.
+func WithAccount(clID, password string, balance int) Option {
+	return func(s *Server) error {
+		if clID == "" || password == "" {
+			return errors.New("Account requires a client ID and a password.")
+		}
+		if balance < 0 {
+			return errors.New("Account balance cannot be negative.")
+		}
+		if _, ok := s.accounts[clID]; ok {
+			return errors.New("Account " + clID + " already exists.")
+		}
+
+		s.accounts[clID] = &account{clID: clID, password: password, balance: balance}
+		return nil
+	}
+}
+
+// WithDomainPrice sets the yearly price of a domain, DefaultDomainPrice by
+// default. Zero makes domains free.
+func WithDomainPrice(price int) Option {
+	return func(s *Server) error {
+		if price < 0 {
+			return errors.New("Domain price cannot be negative.")
+		}
+
+		s.domainPrice = price
+		return nil
+	}
+}
+
+// WithClock sets the function returning the current time of the server,
+// e.g. to test expiring domains. time.Now is used by default.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) error {
+		if now == nil {
+			return errors.New("Clock cannot be nil.")
+		}
+
+		s.now = now
+		return nil
+	}
+}
+
+// WithLogger sets the logger for the commands handled by the server, which
+// logs nothing by default.
+func WithLogger(log *slog.Logger) Option {
+	return func(s *Server) error {
+		if log == nil {
+			return errors.New("Logger cannot be nil.")
+		}
+
+		s.log = log
+		return nil
+	}
+}
+
+// Server is a stateful in-memory FI registry. Its state is shared by all of
+// its connections and safe for concurrent use.
+type Server struct {
+	domainPrice int
+	now         func() time.Time
+	log         *slog.Logger
+
+	mu        sync.Mutex
+	accounts  map[string]*account
+	contacts  map[string]*contact
+	domains   map[string]*domain
+	hosts     map[string]*host
+	contactID int
+	messageID int
+	svTRID    int
+}
+
+type account struct {
+	clID     string
+	password string
+	balance  int
+	messages []message
+}
+
+type message struct {
+	id    string
+	qDate time.Time
+	msg   string
+	name  string
+}
+
+// session is the state of a single connection.
+type session struct {
+	clID string
+}
+
+func New(opts ...Option) (*Server, error) {
+	s := &Server{
+		domainPrice: DefaultDomainPrice,
+		now:         time.Now,
+		log:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		accounts:    make(map[string]*account),
+		contacts:    make(map[string]*contact),
+		domains:     make(map[string]*domain),
+		hosts:       make(map[string]*host),
+	}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Serve runs an EPP session over conn, starting with the greeting, until the
+// client logs out or closes the connection. It can be used as the Serve
+// function of registry.PipeTransport.
+func (s *Server) Serve(conn net.Conn) {
+	defer conn.Close()
+
+	if err := registry.WriteFrame(conn, s.greeting()); err != nil {
+		return
+	}
+
+	sess := &session{}
+	for {
+		req, err := registry.ReadFrame(conn, registry.DefaultMaxFrameSize)
+		if err != nil {
+			return
+		}
+
+		resp, closing := s.handle(sess, req)
+		if err = registry.WriteFrame(conn, resp); err != nil || closing {
+			return
+		}
+	}
+}
+
+// ServeListener serves each connection accepted from l in a new goroutine,
+// until accepting fails, e.g. because l was closed.
+func (s *Server) ServeListener(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.Serve(conn)
+	}
+}
+
+// Balance returns the balance of the account clID.
+func (s *Server) Balance(clID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[clID]
+	if !ok {
+		return 0, errors.New("No account " + clID + ".")
+	}
+
+	return acc.balance, nil
+}
+
+// SetBalance replaces the balance of the account clID.
+func (s *Server) SetBalance(clID string, balance int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[clID]
+	if !ok {
+		return errors.New("No account " + clID + ".")
+	}
+
+	acc.balance = balance
+	return nil
+}
+
+// Enqueue adds a message about the object name to the poll queue of the
+// account clID and returns its ID.
+func (s *Server) Enqueue(clID, msg, name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[clID]
+	if !ok {
+		return "", errors.New("No account " + clID + ".")
+	}
+
+	return s.enqueue(acc, msg, name), nil
+}
+
+// OwnershipChangeKey returns the key the registry would send to the
+// registrant of domain after the sponsoring registrar requested one, or an
+// empty string if none has been requested.
+func (s *Server) OwnershipChangeKey(domain string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dom, ok := s.domains[domain]; ok {
+		return dom.ownershipKey
+	}
+
+	return ""
+}
+
+// RegistryLockKey returns the key the registry would send by SMS for
+// deactivating the registry lock of domain, or an empty string if none has
+// been requested.
+func (s *Server) RegistryLockKey(domain string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dom, ok := s.domains[domain]; ok {
+		return dom.lockKey
+	}
+
+	return ""
+}
+
+// enqueue must be called with mu held.
+func (s *Server) enqueue(acc *account, msg, name string) string {
+	s.messageID++
+	id := randomKey(4) + "-" + strconv.Itoa(s.messageID)
+	acc.messages = append(acc.messages, message{id: id, qDate: s.now(), msg: msg, name: name})
+
+	return id
+}
+
+func randomKey(size int) string {
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(key)
+}
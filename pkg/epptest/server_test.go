@@ -0,0 +1,414 @@
+package epptest
+
+import (
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"github.com/ajmyyra/go-epp-fi/pkg/registry"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+const testPassword = "Test1234!"
+
+// newTestClient returns a client of the account clID, connected to srv and
+// logged in.
+func newTestClient(t *testing.T, srv *Server, clID string) *registry.Client {
+	transport := &registry.PipeTransport{Serve: srv.Serve}
+	client, err := registry.New(
+		registry.WithCredentials(clID, testPassword),
+		registry.WithTransport(transport),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	if err = client.Login(); err != nil {
+		t.Fatalf("Login failed: %v\n", err)
+	}
+
+	t.Cleanup(func() {
+		_ = client.Close()
+		transport.Wait()
+	})
+
+	return client
+}
+
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	opts = append([]Option{
+		WithAccount("registrar1", testPassword, 100),
+		WithAccount("registrar2", testPassword, 100),
+	}, opts...)
+
+	srv, err := New(opts...)
+	if err != nil {
+		t.Fatalf("Creating server failed: %v\n", err)
+	}
+
+	return srv
+}
+
+func createRegistrant(t *testing.T, client *registry.Client) string {
+	registrant, _ := epp.NewPrivatePersonContact(5, true, "Matti", "Meikäläinen", "010101-123N", "Helsinki", "FI",
+		[]string{"Mannerheimintie 1"}, "00100", "matti@example.com", "+358401234567", "")
+
+	id, err := client.CreateContact(registrant)
+	if err != nil {
+		t.Fatalf("Creating registrant failed: %v\n", err)
+	}
+
+	return id
+}
+
+func createDomain(t *testing.T, client *registry.Client, name, registrant string) epp.CreateData {
+	created, err := client.CreateDomain(epp.NewDomainDetails(name, 1, registrant, []string{"ns1.example.com", "ns2.example.com"}))
+	if err != nil {
+		t.Fatalf("Creating domain %s failed: %v\n", name, err)
+	}
+
+	return created
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(WithAccount("registrar", "", 0)); err == nil {
+		t.Error("Account without a password should have failed.")
+	}
+	if _, err := New(WithAccount("registrar", testPassword, 0), WithAccount("registrar", testPassword, 0)); err == nil {
+		t.Error("Duplicate account should have failed.")
+	}
+	if _, err := New(WithDomainPrice(-1)); err == nil {
+		t.Error("Negative price should have failed.")
+	}
+}
+
+func TestServer_Session(t *testing.T) {
+	srv := newTestServer(t)
+	transport := &registry.PipeTransport{Serve: srv.Serve}
+	defer transport.Wait()
+
+	client, err := registry.New(registry.WithCredentials("registrar1", "wrong"), registry.WithTransport(transport))
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	defer client.Close()
+
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	if client.ServerGreeting().SvID != serverID || !client.Supports(epp.SecDNSNamespace) {
+		t.Errorf("Unexpected greeting: %+v\n", client.ServerGreeting())
+	}
+
+	if _, err = client.Balance(); !errors.Is(err, registry.ErrCommandUseError) {
+		t.Errorf("Commands before login should fail with a use error, got: %v\n", err)
+	}
+	if err = client.Login(); !errors.Is(err, registry.ErrAuthenticationError) {
+		t.Errorf("Login with a wrong password should fail, got: %v\n", err)
+	}
+
+	client = newTestClient(t, srv, "registrar1")
+	if _, err = client.Hello(); err != nil {
+		t.Errorf("Hello failed: %v\n", err)
+	}
+	if balance, err := client.Balance(); err != nil || balance != 100 {
+		t.Errorf("Unexpected balance %d: %v\n", balance, err)
+	}
+	if err = client.Logout(); err != nil {
+		t.Errorf("Logout failed: %v\n", err)
+	}
+}
+
+func TestServer_Contacts(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv, "registrar1")
+
+	id := createRegistrant(t, client)
+	contact, err := client.GetContact(id)
+	if err != nil {
+		t.Fatalf("Reading contact failed: %v\n", err)
+	}
+	if contact.Role != 5 || contact.PostalInfo.LastName != "Meikäläinen" || contact.ClID != "registrar1" {
+		t.Errorf("Unexpected contact: %+v\n", contact)
+	}
+
+	checks, err := client.CheckContacts(id, "C999999")
+	if err != nil || len(checks) != 2 || checks[0].IsAvailable || checks[1].Id.Avail != 1 {
+		t.Errorf("Unexpected contact checks %+v: %v\n", checks, err)
+	}
+
+	update, _ := epp.NewPrivatePersonContact(5, true, "Matti", "Virtanen", "010101-123N", "Espoo", "FI",
+		[]string{"Tapiontori 1"}, "02100", "matti@example.com", "+358401234567", "")
+	if err = client.UpdateContact(id, update); err != nil {
+		t.Errorf("Updating contact failed: %v\n", err)
+	}
+	if contact, _ = client.GetContact(id); contact.PostalInfo.Addr.City != "Espoo" {
+		t.Errorf("Contact was not updated: %+v\n", contact)
+	}
+
+	other := newTestClient(t, srv, "registrar2")
+	if _, err = other.GetContact(id); !errors.Is(err, registry.ErrAuthorizationError) {
+		t.Errorf("Reading a contact of another registrar should fail, got: %v\n", err)
+	}
+
+	createDomain(t, client, "contacttest.fi", id)
+	if err = client.DeleteContact(id); !errors.Is(err, registry.ErrObjectAssociationProhibitsOperation) {
+		t.Errorf("Deleting a registrant of a domain should fail, got: %v\n", err)
+	}
+	if err = client.DeleteDomain("contacttest.fi"); err != nil {
+		t.Fatalf("Deleting domain failed: %v\n", err)
+	}
+	if err = client.DeleteContact(id); err != nil {
+		t.Errorf("Deleting contact failed: %v\n", err)
+	}
+	if _, err = client.GetContact(id); !errors.Is(err, registry.ErrObjectDoesNotExist) {
+		t.Errorf("Deleted contact should not exist, got: %v\n", err)
+	}
+}
+
+func TestServer_Domains(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	srv := newTestServer(t, WithClock(func() time.Time { return now }))
+	client := newTestClient(t, srv, "registrar1")
+	registrant := createRegistrant(t, client)
+
+	created := createDomain(t, client, "example.fi", registrant)
+	if !created.ExDate.Equal(now.AddDate(1, 0, 0)) {
+		t.Errorf("Unexpected expiration date: %s\n", created.ExDate)
+	}
+	if balance, _ := srv.Balance("registrar1"); balance != 100-DefaultDomainPrice {
+		t.Errorf("Registration should have been charged, balance: %d\n", balance)
+	}
+
+	if _, err := client.CreateDomain(epp.NewDomainDetails("example.fi", 1, registrant, nil)); !errors.Is(err, registry.ErrObjectExists) {
+		t.Errorf("Registering a registered domain should fail, got: %v\n", err)
+	}
+	if _, err := client.CreateDomain(epp.NewDomainDetails("missing.fi", 1, "C999999", nil)); !errors.Is(err, registry.ErrObjectDoesNotExist) {
+		t.Errorf("Registering with a missing registrant should fail, got: %v\n", err)
+	}
+	if _, err := client.CreateDomain(epp.NewDomainDetails("expensive.fi", 5, registrant, nil)); err != nil {
+		t.Errorf("Registering for five years failed: %v\n", err)
+	}
+	if _, err := client.CreateDomain(epp.NewDomainDetails("broke.fi", 5, registrant, nil)); !errors.Is(err, registry.ErrBillingFailure) {
+		t.Errorf("Registering without balance should fail, got: %v\n", err)
+	}
+
+	checks, err := client.CheckDomains("example.fi", "free.fi")
+	if err != nil || len(checks) != 2 || checks[0].IsAvailable || !checks[1].IsAvailable {
+		t.Errorf("Unexpected domain checks %+v: %v\n", checks, err)
+	}
+
+	if _, err = client.RenewDomain("example.fi", "2024-03-01", 1); !errors.Is(err, registry.ErrParameterValuePolicyError) {
+		t.Errorf("Renewing with a wrong expiration date should fail, got: %v\n", err)
+	}
+	renewed, err := client.RenewDomain("example.fi", "2025-03-01", 1)
+	if err != nil {
+		t.Fatalf("Renewing failed: %v\n", err)
+	}
+	if !renewed.ExDate.Equal(now.AddDate(2, 0, 0)) {
+		t.Errorf("Unexpected expiration date after renewal: %s\n", renewed.ExDate)
+	}
+
+	update := epp.NewDomainUpdateNameservers("example.fi", []string{"ns2.example.com"}, []string{"ns3.example.com"})
+	if err = client.UpdateDomain(update); err != nil {
+		t.Errorf("Updating name servers failed: %v\n", err)
+	}
+	update = epp.NewDomainUpdateNameservers("example.fi", []string{"ns9.example.com"}, nil)
+	if err = client.UpdateDomain(update); !errors.Is(err, registry.ErrObjectDoesNotExist) {
+		t.Errorf("Removing a missing name server should fail, got: %v\n", err)
+	}
+
+	record, _ := epp.NewDomainDNSSecRecord(12345, 13, 2, "ABCDEF0123", 257, 3, 13, "AQPJ////4Q==")
+	if err = client.UpdateDomainExtensions("example.fi", epp.NewDomainDNSSecUpdateExtension([]epp.DomainDSData{record}, nil, false)); err != nil {
+		t.Errorf("Adding DS record failed: %v\n", err)
+	}
+
+	info, err := client.GetDomain("example.fi")
+	if err != nil {
+		t.Fatalf("Reading domain failed: %v\n", err)
+	}
+	if len(info.Ns.HostObj) != 2 || info.Ns.HostObj[1] != "ns3.example.com" || info.Registrant != registrant || info.ClID != "registrar1" {
+		t.Errorf("Unexpected domain info: %+v\n", info)
+	}
+	if len(info.DsData) != 1 || info.DsData[0].KeyTag != 12345 || info.DsData[0].KeyData.PubKey != "AQPJ////4Q==" {
+		t.Errorf("Unexpected DS records: %+v\n", info.DsData)
+	}
+
+	if err = client.UpdateDomainExtensions("example.fi", epp.NewDomainDNSSecUpdateExtension(nil, nil, true)); err != nil {
+		t.Errorf("Removing DS records failed: %v\n", err)
+	}
+	if info, _ = client.GetDomain("example.fi"); len(info.DsData) != 0 {
+		t.Errorf("DS records should have been removed: %+v\n", info.DsData)
+	}
+
+	other := newTestClient(t, srv, "registrar2")
+	if err = other.DeleteDomain("example.fi"); !errors.Is(err, registry.ErrAuthorizationError) {
+		t.Errorf("Deleting a domain of another registrar should fail, got: %v\n", err)
+	}
+	if err = client.DeleteDomain("example.fi"); err != nil {
+		t.Errorf("Deleting domain failed: %v\n", err)
+	}
+	if _, err = client.GetDomain("example.fi"); !errors.Is(err, registry.ErrObjectDoesNotExist) {
+		t.Errorf("Deleted domain should not exist, got: %v\n", err)
+	}
+}
+
+func TestServer_Transfer(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv, "registrar1")
+	createDomain(t, client, "transfer.fi", createRegistrant(t, client))
+
+	other := newTestClient(t, srv, "registrar2")
+	if _, err := other.TransferDomain("transfer.fi", "Wrong-Key1", nil); !errors.Is(err, registry.ErrInvalidAuthorizationInfo) {
+		t.Errorf("Transfer without a key should fail, got: %v\n", err)
+	}
+
+	update, _ := epp.NewDomainUpdateSetTransferKey("transfer.fi", "Transfer-Key1")
+	if err := client.UpdateDomain(update); err != nil {
+		t.Fatalf("Setting transfer key failed: %v\n", err)
+	}
+	if info, _ := client.GetDomain("transfer.fi"); info.AuthInfo.BrokerChangeKey != "Transfer-Key1" {
+		t.Errorf("Transfer key should be shown to the sponsor: %+v\n", info.AuthInfo)
+	}
+	if info, _ := other.GetDomain("transfer.fi"); info.AuthInfo.BrokerChangeKey != "" {
+		t.Error("Transfer key should not be shown to other registrars.")
+	}
+
+	if _, err := other.TransferDomain("transfer.fi", "Wrong-Key1", nil); !errors.Is(err, registry.ErrInvalidAuthorizationInfo) {
+		t.Errorf("Transfer with a wrong key should fail, got: %v\n", err)
+	}
+	transfer, err := other.TransferDomain("transfer.fi", "Transfer-Key1", []string{"ns1.other.net", "ns2.other.net"})
+	if err != nil {
+		t.Fatalf("Transfer failed: %v\n", err)
+	}
+	if transfer.ReID != "registrar2" || transfer.AcID != "registrar1" || transfer.ReDate.IsZero() {
+		t.Errorf("Unexpected transfer data: %+v\n", transfer)
+	}
+
+	info, _ := other.GetDomain("transfer.fi")
+	if info.ClID != "registrar2" || info.Ns.HostObj[0] != "ns1.other.net" || info.TrDate.IsZero() {
+		t.Errorf("Domain should have been transferred: %+v\n", info)
+	}
+
+	msg, err := client.Poll()
+	if err != nil {
+		t.Fatalf("Polling failed: %v\n", err)
+	}
+	if msg.Count != 1 || msg.Name != "transfer.fi" || msg.QDate.IsZero() {
+		t.Errorf("Unexpected poll message: %+v\n", msg)
+	}
+	if left, err := client.PollAck(msg.ID); err != nil || left != 0 {
+		t.Errorf("Acknowledging failed, %d messages left: %v\n", left, err)
+	}
+	if _, err = client.Poll(); err == nil {
+		t.Error("Poll queue should be empty.")
+	}
+	if _, err = client.PollAck(msg.ID); !errors.Is(err, registry.ErrObjectDoesNotExist) {
+		t.Errorf("Acknowledging a missing message should fail, got: %v\n", err)
+	}
+}
+
+func TestServer_OwnershipChange(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv, "registrar1")
+	createDomain(t, client, "owner.fi", createRegistrant(t, client))
+	newRegistrant := createRegistrant(t, client)
+
+	if err := client.UpdateDomain(epp.NewDomainUpdateChangeOwnership("owner.fi", newRegistrant, "guessed")); !errors.Is(err, registry.ErrInvalidAuthorizationInfo) {
+		t.Errorf("Ownership change without a key should fail, got: %v\n", err)
+	}
+
+	if err := client.UpdateDomain(epp.NewDomainUpdateSendOwnershipChangeKey("owner.fi")); err != nil {
+		t.Fatalf("Requesting ownership change key failed: %v\n", err)
+	}
+	key := srv.OwnershipChangeKey("owner.fi")
+	if key == "" {
+		t.Fatal("Ownership change key should have been created.")
+	}
+
+	if err := client.UpdateDomain(epp.NewDomainUpdateChangeOwnership("owner.fi", newRegistrant, key)); err != nil {
+		t.Fatalf("Ownership change failed: %v\n", err)
+	}
+	if info, _ := client.GetDomain("owner.fi"); info.Registrant != newRegistrant {
+		t.Errorf("Registrant should have changed: %s\n", info.Registrant)
+	}
+	if srv.OwnershipChangeKey("owner.fi") != "" {
+		t.Error("Ownership change key should be used only once.")
+	}
+}
+
+func TestServer_RegistryLock(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv, "registrar1")
+	createDomain(t, client, "locked.fi", createRegistrant(t, client))
+
+	activation, _ := epp.NewDomainUpdateActivateRegistryLock("locked.fi", 1, "+358401234567", "+358407654321")
+	if err := client.UpdateDomain(activation); err != nil {
+		t.Fatalf("Activating registry lock failed: %v\n", err)
+	}
+	if info, _ := client.GetDomain("locked.fi"); info.RegistryLock != 1 {
+		t.Error("Domain should be locked.")
+	}
+
+	update := epp.NewDomainUpdateNameservers("locked.fi", nil, []string{"ns3.example.com"})
+	if err := client.UpdateDomain(update); !errors.Is(err, registry.ErrObjectStatusProhibitsOperation) {
+		t.Errorf("Updating a locked domain should fail, got: %v\n", err)
+	}
+	if err := client.DeleteDomain("locked.fi"); !errors.Is(err, registry.ErrObjectStatusProhibitsOperation) {
+		t.Errorf("Deleting a locked domain should fail, got: %v\n", err)
+	}
+
+	if err := client.UpdateDomain(epp.NewDomainUpdateRequestKeyForRegistryLock("locked.fi", 2)); err != nil {
+		t.Fatalf("Requesting registry lock key failed: %v\n", err)
+	}
+	if err := client.UpdateDomain(epp.NewDomainUpdateDeactivateRegistryLock("locked.fi", "wrong", 2)); !errors.Is(err, registry.ErrInvalidAuthorizationInfo) {
+		t.Errorf("Deactivating with a wrong key should fail, got: %v\n", err)
+	}
+	key := srv.RegistryLockKey("locked.fi")
+	if err := client.UpdateDomain(epp.NewDomainUpdateDeactivateRegistryLock("locked.fi", key, 2)); err != nil {
+		t.Fatalf("Deactivating registry lock failed: %v\n", err)
+	}
+	if err := client.UpdateDomain(update); err != nil {
+		t.Errorf("Updating an unlocked domain failed: %v\n", err)
+	}
+}
+
+func TestServer_Hosts(t *testing.T) {
+	srv := newTestServer(t)
+	client := newTestClient(t, srv, "registrar1")
+	createDomain(t, client, "hosts.fi", createRegistrant(t, client))
+
+	if _, err := client.CreateHost("ns1.hosts.fi", nil); !errors.Is(err, registry.ErrRequiredParameterMissing) {
+		t.Errorf("Host under .fi without addresses should fail, got: %v\n", err)
+	}
+	if _, err := client.CreateHost("ns1.unknown.fi", []string{"192.0.2.1"}); !errors.Is(err, registry.ErrObjectDoesNotExist) {
+		t.Errorf("Host under a missing domain should fail, got: %v\n", err)
+	}
+	if _, err := client.CreateHost("ns1.hosts.fi", []string{"192.0.2.1", "2001:db8::1"}); err != nil {
+		t.Fatalf("Creating host failed: %v\n", err)
+	}
+
+	if err := client.UpdateHost("ns1.hosts.fi", []string{"192.0.2.2"}, []string{"2001:db8::1"}); err != nil {
+		t.Errorf("Updating host failed: %v\n", err)
+	}
+	info, err := client.GetHost("ns1.hosts.fi")
+	if err != nil {
+		t.Fatalf("Reading host failed: %v\n", err)
+	}
+	if len(info.Addr) != 2 || info.Addr[1].IP != "192.0.2.2" || info.Addr[1].Family != "v4" {
+		t.Errorf("Unexpected host addresses: %+v\n", info.Addr)
+	}
+
+	update := epp.NewDomainUpdateNameservers("hosts.fi", nil, []string{"ns1.hosts.fi"})
+	if err = client.UpdateDomain(update); err != nil {
+		t.Fatalf("Adding name server failed: %v\n", err)
+	}
+	if err = client.DeleteHost("ns1.hosts.fi"); !errors.Is(err, registry.ErrObjectAssociationProhibitsOperation) {
+		t.Errorf("Deleting a name server in use should fail, got: %v\n", err)
+	}
+	if err = client.DeleteDomain("hosts.fi"); !errors.Is(err, registry.ErrObjectAssociationProhibitsOperation) {
+		t.Errorf("Deleting a domain with hosts should fail, got: %v\n", err)
+	}
+}
@@ -0,0 +1,315 @@
+package epptest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"time"
+)
+
+var greetingTemplate = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <greeting>
+    <svID>` + serverID + `</svID>
+    <svDate>%s</svDate>
+    <svcMenu>
+      <version>1.0</version>
+      <lang>en</lang>
+      <objURI>urn:ietf:params:xml:ns:contact-1.0</objURI>
+      <objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+      <objURI>urn:ietf:params:xml:ns:host-1.0</objURI>
+      <svcExtension>
+        <extURI>urn:ietf:params:xml:ns:secDNS-1.1</extURI>
+        <extURI>urn:ietf:params:xml:ns:domain-ext-1.0</extURI>
+      </svcExtension>
+    </svcMenu>
+    <dcp>
+      <access>
+        <personal />
+      </access>
+      <statement>
+        <purpose>
+          <prov />
+        </purpose>
+        <recipient>
+          <ours />
+          <public />
+        </recipient>
+        <retention>
+          <stated />
+        </retention>
+      </statement>
+    </dcp>
+  </greeting>
+</epp>`
+
+func (s *Server) greeting() []byte {
+	return []byte(xml.Header + fmt.Sprintf(greetingTemplate, s.now().Format(time.RFC3339Nano)))
+}
+
+// handle executes a request and returns the response to it, and whether the
+// connection is closed after sending it.
+func (s *Server) handle(sess *session, payload []byte) ([]byte, bool) {
+	var req request
+	if err := xml.Unmarshal(payload, &req); err != nil {
+		return s.marshal(errorResponse(failure(epp.ResultCommandSyntaxError)), ""), false
+	}
+
+	if req.Hello != nil {
+		return s.greeting(), false
+	}
+	if req.Command == nil {
+		return s.marshal(errorResponse(failure(epp.ResultUnknownCommand)), ""), false
+	}
+
+	s.mu.Lock()
+	resp := s.execute(sess, req.Command)
+	s.mu.Unlock()
+
+	code := resp.Response.Results[0].Code
+	s.log.Debug("Command executed.", "clID", sess.clID, "clTRID", req.Command.ClTRID, "code", int(code))
+
+	closing := code == epp.ResultEndingSession || code.ClosesConnection()
+	return s.marshal(resp, req.Command.ClTRID), closing
+}
+
+// execute must be called with mu held.
+func (s *Server) execute(sess *session, cmd *command) *response {
+	if cmd.Login != nil {
+		return s.login(sess, cmd.Login)
+	}
+	if sess.clID == "" {
+		return errorResponse(&commandError{code: epp.ResultCommandUseError, reason: "Not logged in"})
+	}
+	acc := s.accounts[sess.clID]
+
+	var resp *response
+	var err *commandError
+	switch {
+	case cmd.Logout != nil:
+		sess.clID = ""
+		return newResponse(epp.ResultEndingSession)
+	case cmd.Poll != nil:
+		resp, err = s.poll(acc, cmd.Poll)
+	case cmd.Check != nil:
+		resp, err = s.check(acc, cmd.Check)
+	case cmd.Info != nil:
+		resp, err = s.info(acc, cmd.Info)
+	case cmd.Create != nil:
+		resp, err = s.create(acc, cmd.Create)
+	case cmd.Update != nil:
+		resp, err = s.update(acc, cmd)
+	case cmd.Delete != nil:
+		resp, err = s.delete(acc, cmd.Delete)
+	case cmd.Renew != nil:
+		resp, err = s.renew(acc, cmd.Renew)
+	case cmd.Transfer != nil:
+		resp, err = s.transfer(acc, cmd.Transfer)
+	default:
+		err = failure(epp.ResultUnknownCommand)
+	}
+
+	if err != nil {
+		return errorResponse(err)
+	}
+	return resp
+}
+
+func (s *Server) login(sess *session, login *loginCommand) *response {
+	if sess.clID != "" {
+		return errorResponse(&commandError{code: epp.ResultCommandUseError, reason: "Already logged in"})
+	}
+
+	acc, ok := s.accounts[login.ClID]
+	if !ok || acc.password != login.Pw {
+		return newResponse(epp.ResultAuthenticationError)
+	}
+	if login.NewPW != "" {
+		acc.password = login.NewPW
+	}
+
+	sess.clID = acc.clID
+	return newResponse(epp.ResultCommandCompleted)
+}
+
+func (s *Server) poll(acc *account, poll *pollCommand) (*response, *commandError) {
+	switch poll.Op {
+	case "req":
+		if len(acc.messages) == 0 {
+			return newResponse(epp.ResultNoMessages), nil
+		}
+
+		msg := acc.messages[0]
+		resp := newResponse(epp.ResultAckToDequeue)
+		resp.Response.MsgQ = &msgQ{Count: len(acc.messages), ID: msg.id, QDate: formatDate(msg.qDate), Msg: msg.msg}
+		if msg.name != "" {
+			resp.addData(&transferData{Xmlns: objNamespace, Name: msg.name})
+		}
+		return resp, nil
+	case "ack":
+		for i, msg := range acc.messages {
+			if msg.id == poll.MsgID {
+				acc.messages = append(acc.messages[:i:i], acc.messages[i+1:]...)
+
+				resp := newResponse(epp.ResultCommandCompleted)
+				resp.Response.MsgQ = &msgQ{Count: len(acc.messages), ID: msg.id}
+				return resp, nil
+			}
+		}
+		return nil, &commandError{code: epp.ResultObjectDoesNotExist, reason: "Message " + poll.MsgID + " not found"}
+	}
+
+	return nil, failure(epp.ResultParameterValueSyntaxError)
+}
+
+// check handles checking the availability of objects and the balance of the
+// account, which the FI registry implements as a check command.
+func (s *Server) check(acc *account, cmd *objectCommand) (*response, *commandError) {
+	obj, err := singleObject(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case obj.Name.Local == "balance":
+		resp := newResponse(epp.ResultCommandCompleted)
+		resp.addData(&balanceData{Amount: acc.balance})
+		resp.addData(&timestampData{Date: formatDate(s.now())})
+		return resp, nil
+	case obj.Domain != nil:
+		return s.checkDomains(obj.Domain), nil
+	case obj.Contact != nil:
+		return s.checkContacts(obj.Contact), nil
+	case obj.Host != nil:
+		return s.checkHosts(obj.Host), nil
+	}
+
+	return nil, failure(epp.ResultUnimplementedObjectService)
+}
+
+func (s *Server) info(acc *account, cmd *objectCommand) (*response, *commandError) {
+	obj, err := singleObject(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case obj.Domain != nil:
+		return s.domainInfo(acc, obj.Domain)
+	case obj.Contact != nil:
+		return s.contactInfo(acc, obj.Contact)
+	case obj.Host != nil:
+		return s.hostInfo(obj.Host)
+	}
+
+	return nil, failure(epp.ResultUnimplementedObjectService)
+}
+
+func (s *Server) create(acc *account, cmd *objectCommand) (*response, *commandError) {
+	obj, err := singleObject(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case obj.Domain != nil:
+		return s.createDomain(acc, obj.Domain)
+	case obj.Contact != nil:
+		return s.createContact(acc, obj.Contact)
+	case obj.Host != nil:
+		return s.createHost(acc, obj.Host)
+	}
+
+	return nil, failure(epp.ResultUnimplementedObjectService)
+}
+
+func (s *Server) update(acc *account, cmd *command) (*response, *commandError) {
+	obj, err := singleObject(cmd.Update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case obj.Domain != nil:
+		var secDNS *secDNSUpdate
+		if cmd.Extension != nil {
+			secDNS = cmd.Extension.SecDNSUpdate
+		}
+		return s.updateDomain(acc, obj.Domain, secDNS)
+	case obj.Contact != nil:
+		return s.updateContact(acc, obj.Contact)
+	case obj.Host != nil:
+		return s.updateHost(acc, obj.Host)
+	}
+
+	return nil, failure(epp.ResultUnimplementedObjectService)
+}
+
+func (s *Server) delete(acc *account, cmd *objectCommand) (*response, *commandError) {
+	obj, err := singleObject(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case obj.Domain != nil:
+		return s.deleteDomain(acc, obj.Domain)
+	case obj.Contact != nil:
+		return s.deleteContact(acc, obj.Contact)
+	case obj.Host != nil:
+		return s.deleteHost(acc, obj.Host)
+	}
+
+	return nil, failure(epp.ResultUnimplementedObjectService)
+}
+
+func (s *Server) renew(acc *account, cmd *objectCommand) (*response, *commandError) {
+	obj, err := singleObject(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Domain == nil {
+		return nil, failure(epp.ResultUnimplementedCommand)
+	}
+
+	return s.renewDomain(acc, obj.Domain)
+}
+
+func (s *Server) transfer(acc *account, cmd *objectCommand) (*response, *commandError) {
+	obj, err := singleObject(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Domain == nil {
+		return nil, failure(epp.ResultUnimplementedCommand)
+	}
+	// The FI registry completes transfers immediately, so there are no
+	// pending transfers to query, approve or reject.
+	if cmd.Op != "request" {
+		return nil, failure(epp.ResultUnimplementedOption)
+	}
+
+	return s.transferDomain(acc, obj.Domain)
+}
+
+func singleObject(cmd *objectCommand) (object, *commandError) {
+	if len(cmd.Objects) != 1 {
+		return object{}, failure(epp.ResultCommandSyntaxError)
+	}
+
+	return cmd.Objects[0], nil
+}
+
+func (s *Server) marshal(resp *response, clTRID string) []byte {
+	s.mu.Lock()
+	s.svTRID++
+	resp.Response.TrID.SvTRID = svTRID(s.svTRID)
+	s.mu.Unlock()
+	resp.Response.TrID.ClTRID = clTRID
+
+	payload, err := xml.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		payload, _ = xml.MarshalIndent(errorResponse(failure(epp.ResultCommandFailed)), "", "  ")
+	}
+
+	return append([]byte(xml.Header), payload...)
+}
@@ -1,13 +1,17 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
-	"github.com/pkg/errors"
 )
 
 func (s *Client) Balance() (int, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.BalanceContext(context.Background())
+}
+
+func (s *Client) BalanceContext(ctx context.Context) (int, error) {
+	reqID := s.newTransactionID()
 	balanceReq := epp.APIBalance{}
 	balanceReq.Xmlns = epp.EPPNamespace
 	balanceReq.Command.ClTRID = reqID
@@ -17,9 +21,8 @@ func (s *Client) Balance() (int, error) {
 		return -1, err
 	}
 
-	balanceRawResp, err := s.Send(balanceData)
+	balanceRawResp, err := s.SendContext(ctx, balanceData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return -1, err
 	}
 
@@ -28,8 +31,8 @@ func (s *Client) Balance() (int, error) {
 		return -1, err
 	}
 
-	if balanceResult.Response.Result.Code != 1000 {
-		return -1, errors.New("Request failed: " + balanceResult.Response.Result.Msg)
+	if !balanceResult.Response.Results.Code().IsSuccess() {
+		return -1, newEPPError(balanceResult.Response.Results, balanceResult.Response.TrID)
 	}
 
 	return balanceResult.Response.ResData.BalanceAmount, nil
@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// DefaultExpiryWarning is how long before the client certificate expires a
+// warning is logged when creating a client, unless set with WithExpiryWarning.
+const DefaultExpiryWarning = 30 * 24 * time.Hour
+
+// ClientCertificate returns the client certificate in use, or nil if it is
+// provided with tls.Config.GetClientCertificate.
+func (s *Client) ClientCertificate() *x509.Certificate {
+	if s.certReloader != nil {
+		return s.certReloader.Certificate()
+	}
+
+	return s.clientCert
+}
+
+// CertificateExpiresAt returns when the client certificate expires, or the
+// zero time if the certificate is not known.
+func (s *Client) CertificateExpiresAt() time.Time {
+	cert := s.ClientCertificate()
+	if cert == nil {
+		return time.Time{}
+	}
+
+	return cert.NotAfter
+}
+
+// ServerCertificates returns the certificates presented by the registry in
+// the latest connection, starting with the server's own certificate.
+func (s *Client) ServerCertificates() []*x509.Certificate {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	return s.serverCerts
+}
+
+func (s *Client) setServerCertificates(certs []*x509.Certificate) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	s.serverCerts = certs
+}
+
+// checkCertificateExpiry logs a warning if the client certificate has
+// expired or expires within window.
+func (s *Client) checkCertificateExpiry(window time.Duration) {
+	cert := s.ClientCertificate()
+	if cert == nil || window <= 0 {
+		return
+	}
+
+	remaining := time.Until(cert.NotAfter)
+	switch {
+	case remaining <= 0:
+		s.log.Warn("Client certificate has expired.", "subject", cert.Subject.String(), "notAfter", cert.NotAfter)
+	case remaining <= window:
+		s.log.Warn("Client certificate expires soon.", "subject", cert.Subject.String(), "notAfter", cert.NotAfter,
+			"daysRemaining", int(remaining.Hours()/24))
+	}
+}
+
+// handshakeError explains a failed TLS handshake with the state of the client
+// certificate, as the registry only reports a generic TLS alert for it.
+func (s *Client) handshakeError(err error) error {
+	cert := s.ClientCertificate()
+	if cert == nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.After(cert.NotAfter) {
+		return errors.Wrapf(err, "Client certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(cert.NotBefore) {
+		return errors.Wrapf(err, "Client certificate is valid only from %s", cert.NotBefore.Format(time.RFC3339))
+	}
+
+	return err
+}
+
+func leafCertificate(cert tls.Certificate) (*x509.Certificate, error) {
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("Client certificate is empty.")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid client certificate")
+	}
+
+	return leaf, nil
+}
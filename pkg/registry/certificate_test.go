@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_CertificateExpiry(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12016)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+	clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+	caCert, _ := ioutil.ReadFile("../../testtmp/rootCA.crt")
+
+	block, _ := pem.Decode(clientCert)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("Parsing test certificate failed: %v\n", err)
+	}
+
+	var logs bytes.Buffer
+	newClient := func(certPEM, keyPEM []byte, window time.Duration) (*Client, error) {
+		logs.Reset()
+		return New(
+			WithServer("127.0.0.1", 12016),
+			WithCredentials("test", "test123"),
+			WithClientCertificate(certPEM, keyPEM),
+			WithCACertificates(caCert),
+			WithExpiryWarning(window),
+			WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		)
+	}
+
+	client, err := newClient(clientCert, clientKey, time.Hour)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if !client.CertificateExpiresAt().Equal(leaf.NotAfter) {
+		t.Errorf("Unexpected expiry time %s, expected %s\n", client.CertificateExpiresAt(), leaf.NotAfter)
+	}
+	if logs.Len() > 0 {
+		t.Errorf("No warning expected for a valid certificate, got: %s\n", logs.String())
+	}
+
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	serverCerts := client.ServerCertificates()
+	if len(serverCerts) == 0 || serverCerts[0].Subject.CommonName == "" {
+		t.Error("Server certificates should be available after connecting.")
+	}
+	_ = client.Close()
+
+	if _, err = newClient(clientCert, clientKey, time.Until(leaf.NotAfter)+time.Hour); err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if !strings.Contains(logs.String(), "Client certificate expires soon.") {
+		t.Errorf("Expected a warning of the certificate expiring, got: %s\n", logs.String())
+	}
+
+	expiredCert, expiredKey, err := createExpiredCertificate()
+	if err != nil {
+		t.Fatalf("Creating expired certificate failed: %v\n", err)
+	}
+	client, err = newClient(expiredCert, expiredKey, DefaultExpiryWarning)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if !strings.Contains(logs.String(), "Client certificate has expired.") {
+		t.Errorf("Expected a warning of the expired certificate, got: %s\n", logs.String())
+	}
+
+	err = client.Connect()
+	if err == nil {
+		_ = client.Close()
+		t.Fatal("Connecting with an expired certificate should have failed.")
+	}
+	if !strings.Contains(err.Error(), "Client certificate expired at") {
+		t.Errorf("Error should explain the certificate has expired, got: %v\n", err)
+	}
+
+	if _, err = New(WithServer("127.0.0.1", 12016), WithCredentials("test", "test123"),
+		WithClientCertificate(clientCert, clientKey), WithExpiryWarning(-time.Hour)); err == nil {
+		t.Error("Negative expiry warning window should have failed.")
+	}
+}
+
+func TestClient_ServerCertificatesOnFailedVerification(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12018)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+	clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+
+	// The test CA is not among the system roots, so the chain does not verify.
+	client, err := New(
+		WithServer("127.0.0.1", 12018),
+		WithCredentials("test", "test123"),
+		WithClientCertificate(clientCert, clientKey),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+
+	if err = client.Connect(); err == nil {
+		_ = client.Close()
+		t.Fatal("Connecting with an unknown CA should have failed.")
+	}
+	serverCerts := client.ServerCertificates()
+	if len(serverCerts) == 0 || serverCerts[0].Subject.CommonName == "" {
+		t.Error("Certificates presented by the server should be available after a failed verification.")
+	}
+}
+
+func TestClient_ClientCertificateRejected(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12018)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	// The test server only accepts client certificates issued by the test CA.
+	clientCert, clientKey, err := createSelfSignedCertificate("unknown", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Creating client certificate failed: %v\n", err)
+	}
+	caCert, _ := ioutil.ReadFile("../../testtmp/rootCA.crt")
+
+	// With TLS 1.2 the server rejects the certificate during the handshake.
+	client, err := New(
+		WithServer("127.0.0.1", 12018),
+		WithCredentials("test", "test123"),
+		WithClientCertificate(clientCert, clientKey),
+		WithCACertificates(caCert),
+		WithTLSConfig(&tls.Config{MaxVersion: tls.VersionTLS12}),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+
+	err = client.Connect()
+	if err == nil {
+		_ = client.Close()
+		t.Fatal("Connecting with a certificate unknown to the server should have failed.")
+	}
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) || strings.Contains(err.Error(), "failed to verify certificate") {
+		t.Errorf("Error should not claim the server certificate failed to verify, got: %v\n", err)
+	}
+	if len(client.ServerCertificates()) == 0 {
+		t.Error("Certificates presented by the server should be available after the handshake failed.")
+	}
+}
+
+func createExpiredCertificate() ([]byte, []byte, error) {
+	return createSelfSignedCertificate("expired", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+}
+
+func createSelfSignedCertificate(name string, notBefore, notAfter time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
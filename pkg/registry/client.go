@@ -1,31 +1,72 @@
 package registry
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"fmt"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
-	"github.com/gemalto/flume"
 	"github.com/pkg/errors"
-	"math/rand"
-	"net"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Client is safe for concurrent use. Commands issued from several goroutines
+// are executed one at a time over the single connection to the registry.
 type Client struct {
-	registryServer string
-	tlsConfig      tls.Config
+	transport      Transport
+	// tlsConfig of the default transport, for SetCACertificates.
+	tlsConfig      *tls.Config
+	clientCert     *x509.Certificate
+	certReloader   *CertificateReloader
+	transcriptFile *os.File
+	// Guarded by stateMu, as ChangePassword replaces the password.
 	credentials    Credentials
 
-	conn           net.Conn
+	connected      bool
+	exchange       chan struct{}
 	sendWaitTime   time.Duration
 	readTimeout    time.Duration
 	writeTimeout   time.Duration
+	retryPolicy    RetryPolicy
+	idGenerator    TransactionIDGenerator
 
-	log            flume.Logger
+	// Time of the latest request in UnixNano, for the keepalive.
+	lastSent        atomic.Int64
+	keepaliveMu     sync.Mutex
+	keepaliveCancel context.CancelFunc
+	keepaliveDone   chan struct{}
 
+	log            *slog.Logger
+	unsafeLogging  bool
+
+	// Greeting and LoggedIn are kept for compatibility. Use ServerGreeting
+	// and IsLoggedIn when the client is shared between goroutines.
+	stateMu        sync.RWMutex
 	Greeting       epp.Greeting
 	LoggedIn       bool
+	serverCerts    []*x509.Certificate
+
+	// Session state to restore when reconnecting.
+	wantConnected  bool
+	wantLoggedIn   bool
+}
+
+// RetryPolicy controls recovering from dropped connections and sessions
+// terminated by the server. When enabled, the client reconnects, logs back in
+// if it was logged in, and sends the interrupted command again if it is safe
+// to do so (check, info, poll request and hello). Other commands are sent
+// again only if they were not written to the lost connection. Otherwise they
+// fail with ErrNotRetried once the session has been restored, as they may
+// already have been executed by the server.
+type RetryPolicy struct {
+	// MaxRetries is the amount of reconnection attempts for a single
+	// command. Zero disables reconnecting.
+	MaxRetries int
+	// Delay is the time waited between reconnection attempts.
+	Delay time.Duration
 }
 
 type Credentials struct {
@@ -33,43 +74,68 @@ type Credentials struct {
 	password string
 }
 
+// NewRegistryClient creates a client for the given server with PEM encoded
+// client key and certificate. See New for more options.
 func NewRegistryClient(username, password, serverHost string, serverPort int, clientKey, clientCert []byte) (*Client, error) {
-	cert, err := tls.X509KeyPair(clientCert, clientKey)
-	if err != nil {
-		return nil, err
-	}
+	return New(
+		WithServer(serverHost, serverPort),
+		WithCredentials(username, password),
+		WithClientCertificate(clientCert, clientKey),
+	)
+}
 
-	registry := fmt.Sprintf("%s:%d", serverHost, serverPort)
+// IsLoggedIn reports whether the client has a logged in session.
+func (s *Client) IsLoggedIn() bool {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
 
-	client := Client{
-		registryServer: registry,
-		sendWaitTime:   time.Duration(1) * time.Second,
-		readTimeout:    time.Duration(60) * time.Second,
-		writeTimeout:   time.Duration(60) * time.Second,
-		conn:           nil,
-	}
-	client.credentials = Credentials{
-		username: username,
-		password: password,
-	}
-	client.tlsConfig = tls.Config{
-		Certificates: []tls.Certificate{cert},
-	}
+	return s.LoggedIn
+}
 
-	loggingConfig := "{\"level\":\"INF\"}"
-	if err = flume.ConfigString(loggingConfig); err != nil {
-		return nil, err
-	}
-	if err = flume.ConfigFromEnv(); err != nil {
-		return nil, err
-	}
-	client.log = flume.New("FI EPP")
+// ServerGreeting returns the greeting received when connecting to the server.
+func (s *Client) ServerGreeting() epp.Greeting {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
 
+	return s.Greeting
+}
 
-	// For request ID generation
-	rand.Seed(time.Now().UnixNano())
+func (s *Client) setLoggedIn(loggedIn bool) {
+	s.stateMu.Lock()
+	s.LoggedIn = loggedIn
+	s.stateMu.Unlock()
+}
 
-	return &client, nil
+func (s *Client) loginCredentials() Credentials {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.credentials
+}
+
+func (s *Client) setPassword(password string) {
+	s.stateMu.Lock()
+	s.credentials.password = password
+	s.stateMu.Unlock()
+}
+
+func (s *Client) setGreeting(greeting epp.Greeting) {
+	s.stateMu.Lock()
+	s.Greeting = greeting
+	s.stateMu.Unlock()
+}
+
+func (s *Client) setSessionWanted(connected, loggedIn bool) {
+	s.stateMu.Lock()
+	s.wantConnected = connected
+	s.wantLoggedIn = loggedIn
+	s.stateMu.Unlock()
+}
+
+func (s *Client) sessionWanted() (bool, bool) {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	return s.wantConnected, s.wantLoggedIn
 }
 
 func (s *Client) SetCACertificates(caCerts []byte) error {
@@ -82,10 +148,24 @@ func (s *Client) SetCACertificates(caCerts []byte) error {
 	return nil
 }
 
+// SetSendWaitTime makes the client wait for the given duration between
+// writing a request and reading its response. Responses are read based on
+// their length prefix, so this is disabled by default and only exists for
+// compatibility with servers that misbehave when read from too eagerly.
 func (s *Client) SetSendWaitTime(dur time.Duration) {
 	s.sendWaitTime = dur
 }
 
+func (s *Client) SetRetryPolicy(policy RetryPolicy) error {
+	if policy.MaxRetries < 0 || policy.Delay < 0 {
+		return errors.New("Retry policy values must not be negative.")
+	}
+
+	s.retryPolicy = policy
+
+	return nil
+}
+
 func (s *Client) SetReadTimeout(seconds int) error {
 	if seconds <= 0 {
 		return errors.New("Read timeout must be a positive integer.")
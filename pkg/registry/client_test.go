@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClient_ConcurrentCommands(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12006)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	workers := 20
+	requestsPerWorker := 10
+	for i := 0; i < workers*requestsPerWorker; i++ {
+		eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*requestsPerWorker)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < requestsPerWorker; j++ {
+				info, err := eppTestClient.GetDomain("testdomain2.fi")
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if info.Name != "testdomain2.fi" {
+					t.Errorf("Unexpected domain in response: %s\n", info.Name)
+				}
+
+				_ = eppTestClient.IsLoggedIn()
+				_ = eppTestClient.ServerGreeting()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent domain info failed: %v\n", err)
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
+func TestClient_MismatchedTransactionID(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12006)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	staleResponse := strings.Replace(successfulCommandResponse, "REPLACE_REQ_ID", "STALE", 1)
+	eppTestServer.SetupNewResponses(expectedDomainDeletion, staleResponse, staleResponse)
+
+	if err = eppTestClient.DeleteDomain("testdomain.fi"); err == nil {
+		t.Error("Response with another clTRID should have been rejected.")
+	}
+
+	if _, err = eppTestClient.Hello(); err != ErrNotConnected {
+		t.Errorf("Connection should have been closed after a mismatched response, got: %v\n", err)
+	}
+}
+
+func TestClient_RequestLogging(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12006)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	var logs bytes.Buffer
+	eppTestClient.log = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+	if _, err = eppTestClient.GetDomain("testdomain2.fi"); err != nil {
+		t.Fatalf("Fetching domain failed: %v\n", err)
+	}
+
+	var entry map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var e map[string]interface{}
+		if err = json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("Invalid log line %s: %v\n", line, err)
+		}
+		if e["msg"] == "Request completed." {
+			entry = e
+		}
+	}
+
+	if entry == nil {
+		t.Fatalf("Request was not logged: %s\n", logs.String())
+	}
+	if entry["command"] != "info" || entry["svTRID"] != "54322-XYZ" || entry["resultCode"] != float64(1000) {
+		t.Errorf("Unexpected request log entry: %v\n", entry)
+	}
+	if clTRID, _ := entry["clTRID"].(string); clTRID == "" {
+		t.Errorf("Request log entry is missing clTRID: %v\n", entry)
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Errorf("Request log entry is missing duration: %v\n", entry)
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
+func TestSummarizeMessage(t *testing.T) {
+	summary := summarizeMessage([]byte(successfulLogin))
+	if summary.ClTRID != "REPLACE_REQ_ID" {
+		t.Errorf("Unexpected clTRID: %s\n", summary.ClTRID)
+	}
+	if summary.SvTRID != "wp3dozy" {
+		t.Errorf("Unexpected svTRID: %s\n", summary.SvTRID)
+	}
+	if summary.ResultCode != 1000 {
+		t.Errorf("Unexpected result code: %d\n", summary.ResultCode)
+	}
+
+	if summary = summarizeMessage([]byte(greeting)); summary.ClTRID != "" || summary.SvTRID != "" {
+		t.Errorf("Greeting should not contain transaction IDs: %+v\n", summary)
+	}
+
+	requests := map[string]bool{
+		helloReq:               true,
+		expectedDomainInfo:     true,
+		expectedDomainCheck:    true,
+		expectedPollReq:        true,
+		expectedPollAck:        false,
+		expectedLogin:          false,
+		expectedDomainDeletion: false,
+	}
+	for request, idempotent := range requests {
+		if summary = summarizeMessage([]byte(request)); summary.isIdempotent() != idempotent {
+			t.Errorf("Command %s %s should have idempotency %t\n", summary.Command, summary.Op, idempotent)
+		}
+	}
+}
@@ -1,126 +1,391 @@
 package registry
 
 import (
-	"bytes"
-	"crypto/tls"
-	"encoding/binary"
+	"context"
 	"encoding/xml"
 	"github.com/pkg/errors"
-	"io"
 	"net"
+	"os"
 	"time"
 )
 
 const APIVersion = "1.0"
 const APILanguage = "en"
 
+var ErrNotConnected = errors.New("Uninitialized connection, unable to connect to server.")
+var ErrNotRetried = errors.New("Session was restored, but the command was not sent again as it may already have been executed.")
+
 func (s *Client) Connect() error {
-	dialConn, err := tls.Dial("tcp", s.registryServer, &s.tlsConfig)
-	if err != nil {
+	return s.ConnectContext(context.Background())
+}
+
+func (s *Client) ConnectContext(ctx context.Context) error {
+	if err := s.acquire(ctx); err != nil {
 		return err
 	}
-	s.conn = dialConn
+	defer s.release()
 
-	greet, err := s.Read()
-	if err != nil {
+	if err := s.connect(ctx); err != nil {
+		s.closeTransport()
 		return err
 	}
 
-	s.Greeting, err = unmarshalGreeting(greet)
+	s.setSessionWanted(true, false)
+	return nil
+}
+
+func (s *Client) connect(ctx context.Context) error {
+	if err := s.transport.Connect(ctx); err != nil {
+		// The chain the server presented is available for diagnosing why
+		// it was not accepted.
+		if certer, ok := s.transport.(peerCertificater); ok {
+			s.setServerCertificates(certer.PeerCertificates())
+		}
+		return s.handshakeError(err)
+	}
+	s.connected = true
+	if stater, ok := s.transport.(connectionStater); ok {
+		s.setServerCertificates(stater.ConnectionState().PeerCertificates)
+	}
+
+	// With TLS 1.3 the server rejects the client certificate only after the
+	// handshake, failing the read of the greeting.
+	greet, err := s.readFrame(ctx)
+	if err != nil {
+		return s.handshakeError(err)
+	}
+
+	greeting, err := unmarshalGreeting(greet)
 	if err != nil {
 		return err
 	}
+	s.setGreeting(greeting)
 
-	if s.Greeting.SvcMenu.Version != APIVersion {
-		return errors.New("Unexpected version: " + s.Greeting.SvcMenu.Version)
+	if greeting.SvcMenu.Version != APIVersion {
+		return errors.New("Unexpected version: " + greeting.SvcMenu.Version)
 	}
 
-	return nil
+	return s.checkServices(greeting)
 }
 
 func (s *Client) Read() ([]byte, error) {
-	var rawResponse int32
+	return s.ReadContext(context.Background())
+}
 
-	if s.readTimeout > 0 {
-		s.conn.SetReadDeadline(time.Now().Add(s.readTimeout))
+// ReadContext reads a single frame from the server. If the read fails or ctx
+// is done before a whole frame has been received, the connection is closed
+// as the framing of the stream can no longer be trusted.
+func (s *Client) ReadContext(ctx context.Context) ([]byte, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
 	}
+	defer s.release()
 
-	err := binary.Read(s.conn, binary.BigEndian, &rawResponse)
-	if err != nil {
+	return s.readFrame(ctx)
+}
+
+func (s *Client) readFrame(ctx context.Context) ([]byte, error) {
+	if !s.connected {
+		return nil, ErrNotConnected
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	rawResponse -= 4
-	if rawResponse < 0 {
-		return nil, io.ErrUnexpectedEOF
-	}
+	ioCtx, cancel := ioContext(ctx, s.readTimeout)
+	defer cancel()
 
-	bytesResponse, err := readStreamToBytes(s.conn, rawResponse)
+	frame, err := s.transport.ReadFrame(ioCtx)
 	if err != nil {
-		return nil, err
+		return nil, s.abortConnection(ctx, err)
 	}
 
-	return bytesResponse, nil
+	return frame, nil
 }
 
 func (s *Client) Write(payload []byte) error {
-	payload = []byte(xml.Header + string(payload))
-
-	sendBytesLength := uint32(4 + len(payload))
+	return s.WriteContext(context.Background(), payload)
+}
 
-	if s.writeTimeout > 0 {
-		s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+// WriteContext writes payload to the server as a single frame. As with
+// ReadContext, the connection is closed if the frame could not be written
+// completely.
+func (s *Client) WriteContext(ctx context.Context, payload []byte) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
 	}
+	defer s.release()
 
-	err := binary.Write(s.conn, binary.BigEndian, sendBytesLength)
-	if err != nil {
-		return err
+	return s.writeFrame(ctx, payload)
+}
+
+func (s *Client) writeFrame(ctx context.Context, payload []byte) error {
+	if !s.connected {
+		return ErrNotConnected
 	}
-	if _, err = s.conn.Write(payload); err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
-		// TODO log first param (amount of bytes written) if error
+	}
+
+	payload = []byte(xml.Header + string(payload))
+
+	ioCtx, cancel := ioContext(ctx, s.writeTimeout)
+	defer cancel()
+
+	if err := s.transport.WriteFrame(ioCtx, payload); err != nil {
+		return s.abortConnection(ctx, err)
 	}
 
 	return nil
 }
 
 func (s *Client) Send(payload []byte) ([]byte, error) {
-	s.log.Debug("Sending message:\n" + string(payload))
-	err := s.Write(payload)
-	if err != nil {
-		// TODO log error
+	return s.SendContext(context.Background(), payload)
+}
+
+// SendContext writes payload to the server and waits for the response to it.
+// Concurrent calls are queued and executed one at a time, and the response is
+// checked to belong to the request by its clTRID. If a retry policy has been
+// set, lost connections and sessions are restored as described in RetryPolicy.
+// Transaction IDs of the exchange can be recorded with WithTransaction.
+func (s *Client) SendContext(ctx context.Context, payload []byte) ([]byte, error) {
+	if err := s.acquire(ctx); err != nil {
 		return nil, err
 	}
+	defer s.release()
+
+	start := time.Now()
+	apiResp, err := s.sendWithRetries(ctx, payload)
+	recordTransaction(ctx, payload, apiResp)
+	s.logRequest(ctx, payload, apiResp, err, time.Since(start))
+
+	return apiResp, err
+}
+
+func (s *Client) sendWithRetries(ctx context.Context, payload []byte) ([]byte, error) {
+	apiResp, written, err := s.exchangeFrames(ctx, payload)
+	if s.retryPolicy.MaxRetries == 0 || !s.sessionLost(ctx, apiResp, err) {
+		return apiResp, err
+	}
+
+	command := summarizeMessage(payload)
+	for attempt := 1; attempt <= s.retryPolicy.MaxRetries; attempt++ {
+		if attempt > 1 && s.retryPolicy.Delay > 0 {
+			select {
+			case <-time.After(s.retryPolicy.Delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		s.log.Warn("Connection to registry lost, reconnecting.", "attempt", attempt, "clTRID", command.ClTRID)
+		if reconnErr := s.reconnect(ctx); reconnErr != nil {
+			s.log.Error("Reconnecting to registry failed.", "attempt", attempt, "error", reconnErr)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		// A command that never reached the server is safe to send again.
+		if written && !command.isIdempotent() {
+			cause := "session terminated by server"
+			if err != nil {
+				cause = err.Error()
+			}
+			return nil, errors.Wrap(ErrNotRetried, cause)
+		}
+
+		apiResp, written, err = s.exchangeFrames(ctx, payload)
+		if !s.sessionLost(ctx, apiResp, err) {
+			return apiResp, err
+		}
+	}
+
+	return apiResp, err
+}
+
+// exchangeFrames writes payload and reads the response to it. The returned
+// bool tells whether the whole frame was written, and the server may have
+// executed the command even if reading the response failed.
+func (s *Client) exchangeFrames(ctx context.Context, payload []byte) ([]byte, bool, error) {
+	s.logMessage(ctx, "Sending message.", payload)
+	err := s.writeFrame(ctx, payload)
+	if err != nil {
+		return nil, false, err
+	}
+	s.lastSent.Store(time.Now().UnixNano())
 
-	time.Sleep(s.sendWaitTime)
+	if s.sendWaitTime > 0 {
+		select {
+		case <-time.After(s.sendWaitTime):
+		case <-ctx.Done():
+			return nil, true, s.abortConnection(ctx, ctx.Err())
+		}
+	}
 
-	apiResp, err := s.Read()
+	apiResp, err := s.readFrame(ctx)
 	if err != nil {
-		// TODO log error
-		return nil, err
+		return nil, true, err
+	}
+
+	s.logMessage(ctx, "Received response.", apiResp)
+
+	reqID := summarizeMessage(payload).ClTRID
+	respID := summarizeMessage(apiResp).ClTRID
+	if reqID != "" && respID != "" && reqID != respID {
+		err = errors.Errorf("Received response to transaction %s while waiting for %s", respID, reqID)
+		return nil, true, s.abortConnection(ctx, err)
 	}
 
-	s.log.Debug("Received response:\n" + string(apiResp))
+	return apiResp, true, nil
+}
+
+// sessionLost tells whether an exchange failed because the connection was
+// dropped or the server ended the session, and the session should be restored.
+func (s *Client) sessionLost(ctx context.Context, apiResp []byte, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if wantConnected, _ := s.sessionWanted(); !wantConnected {
+		return false
+	}
 
-	return apiResp, nil
+	if err != nil {
+		return !s.connected
+	}
+
+	return summarizeMessage(apiResp).ResultCode.ClosesConnection()
+}
+
+// reconnect replaces the current connection with a new one, logging in
+// again if the lost session was logged in.
+func (s *Client) reconnect(ctx context.Context) error {
+	_, wantLoggedIn := s.sessionWanted()
+
+	s.closeTransport()
+	s.setLoggedIn(false)
+
+	if err := s.connect(ctx); err != nil {
+		s.closeTransport()
+		return err
+	}
+
+	if !wantLoggedIn {
+		return nil
+	}
+
+	loginData, err := s.loginMessage("")
+	if err != nil {
+		return err
+	}
+
+	rawResult, _, err := s.exchangeFrames(ctx, loginData)
+	if err != nil {
+		return errors.Wrap(err, "Login failed")
+	}
+
+	return s.handleLoginResult(rawResult)
 }
 
 func (s *Client) Close() error {
-	if err := s.conn.Close(); err != nil {
+	s.StopKeepalive()
+
+	if err := s.acquire(context.Background()); err != nil {
 		return err
 	}
+	defer s.release()
 
-	s.conn = nil
-	return nil
+	s.setSessionWanted(false, false)
+
+	var err error
+	if s.connected {
+		s.connected = false
+		err = s.transport.Close()
+	}
+	if transcriptErr := s.closeTranscript(); err == nil {
+		err = transcriptErr
+	}
+
+	return err
 }
 
-func readStreamToBytes(conn net.Conn, rawResponse int32) ([]byte, error) {
-	lr := io.LimitedReader{R: conn, N: int64(rawResponse)}
+// closeTranscript closes the transcript file opened by WithTranscriptFile.
+func (s *Client) closeTranscript() error {
+	if s.transcriptFile == nil {
+		return nil
+	}
 
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(&lr); err != nil {
-		return nil, err
-		// TODO log first param (amount of bytes read) if error
+	err := s.transcriptFile.Sync()
+	if closeErr := s.transcriptFile.Close(); err == nil {
+		err = closeErr
+	}
+	s.transcriptFile = nil
+
+	return err
+}
+
+func (s *Client) isConnected() bool {
+	if err := s.acquire(context.Background()); err != nil {
+		return false
+	}
+	defer s.release()
+
+	return s.connected
+}
+
+// acquire reserves the connection for the calling goroutine, waiting for
+// earlier commands to finish first.
+func (s *Client) acquire(ctx context.Context) error {
+	select {
+	case s.exchange <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Client) release() {
+	<-s.exchange
+}
+
+// abortConnection closes a connection left in an unknown state by a failed
+// read or write. If the failure was caused by ctx, its error is returned
+// instead of the resulting I/O error.
+func (s *Client) abortConnection(ctx context.Context, err error) error {
+	s.closeTransport()
+	s.setLoggedIn(false)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	// The I/O deadline may pass just before the context notices its own.
+	if deadline, ok := ctx.Deadline(); ok && isTimeout(err) && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	return err
+}
+
+// isTimeout tells whether an I/O operation failed because its deadline passed.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// closeTransport closes the connection of the transport, also when it was
+// left half open by a failed connect.
+func (s *Client) closeTransport() {
+	_ = s.transport.Close()
+	s.connected = false
+}
+
+// ioContext limits ctx by the given I/O timeout, if it is not already done
+// earlier.
+func ioContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
 	}
-	return buf.Bytes(), nil
+
+	return context.WithTimeout(ctx, timeout)
 }
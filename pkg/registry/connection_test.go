@@ -1,7 +1,11 @@
 package registry
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConnectionAndHello(t *testing.T) {
@@ -26,6 +30,214 @@ func TestConnectionAndHello(t *testing.T) {
 	}
 }
 
+func TestConnectionContextCancellation(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12001)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = eppTestClient.ConnectContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Connecting with a cancelled context should have failed, got: %v\n", err)
+	}
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	// No response is queued, so the server never answers.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err = eppTestClient.GetDomainContext(ctx, "testdomain.fi"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline to be exceeded, got: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Request was not interrupted in time, took %s\n", elapsed)
+	}
+
+	if _, err = eppTestClient.Hello(); err == nil {
+		t.Error("Interrupted connection should have been closed.")
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
+func TestAutomaticReconnect(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12001)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.SetRetryPolicy(RetryPolicy{MaxRetries: 2}); err != nil {
+		t.Fatalf("Setting retry policy failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	if err = eppTestClient.Login(); err != nil {
+		t.Fatalf("Login failed: %v\n", err)
+	}
+
+	// Info is safe to send again after logging back in.
+	eppTestServer.SetupNewResponses(expectedDomainInfo, dropConnection, dropConnection)
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+	eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+
+	if _, err = eppTestClient.GetDomain("testdomain2.fi"); err != nil {
+		t.Errorf("Domain info should have been retried after reconnecting: %v\n", err)
+	}
+
+	// Session terminated by the server is restored as well.
+	eppTestServer.SetupNewResponses(expectedDomainCheck, sessionTerminated, sessionTerminated)
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+	eppTestServer.SetupNewResponses(expectedDomainCheck, domainCheckResponse, failedCommand)
+
+	if _, err = eppTestClient.CheckDomains("testdomain1.fi", "testdomain2.fi", "testdomain3.fi"); err != nil {
+		t.Errorf("Domain check should have been retried after reconnecting: %v\n", err)
+	}
+
+	// Deletion may already have happened, so it must not be sent again.
+	eppTestServer.SetupNewResponses(expectedDomainDeletion, dropConnection, dropConnection)
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+
+	if err = eppTestClient.DeleteDomain("testdomain.fi"); !errors.Is(err, ErrNotRetried) {
+		t.Errorf("Domain deletion should not have been retried, got: %v\n", err)
+	}
+
+	// Deletion is sent after reconnecting if the connection was lost before
+	// it was written.
+	_ = eppTestClient.acquire(context.Background())
+	_ = eppTestClient.abortConnection(context.Background(), nil)
+	eppTestClient.release()
+
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+	eppTestServer.SetupNewResponses(expectedDomainDeletion, successfulCommandResponse, failedCommand)
+
+	if err = eppTestClient.DeleteDomain("testdomain3.fi"); err != nil {
+		t.Errorf("Domain deletion should have been sent after reconnecting: %v\n", err)
+	}
+
+	if !eppTestClient.IsLoggedIn() {
+		t.Error("Session should have been restored after reconnecting.")
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
+func TestGreetingDataCollectionPolicy(t *testing.T) {
+	greet, err := unmarshalGreeting([]byte(greeting))
+	if err != nil {
+		t.Fatalf("Unmarshalling greeting failed: %v\n", err)
+	}
+
+	dcp := greet.DCP
+	if strings.Join(dcp.Access, " ") != "personal" || len(dcp.Statements) != 1 || dcp.Expiry != nil {
+		t.Fatalf("Unexpected data collection policy: %+v\n", dcp)
+	}
+	statement := dcp.Statements[0]
+	if strings.Join(statement.Purpose, " ") != "prov" ||
+		strings.Join(statement.Recipient, " ") != "ours public" ||
+		strings.Join(statement.Retention, " ") != "stated" {
+		t.Errorf("Unexpected data collection statement: %+v\n", statement)
+	}
+
+	greet, err = unmarshalGreeting([]byte(greetingWithPolicyDetails))
+	if err != nil {
+		t.Fatalf("Unmarshalling greeting failed: %v\n", err)
+	}
+
+	dcp = greet.DCP
+	if len(dcp.Statements) != 2 || dcp.Expiry == nil || dcp.Expiry.Relative != "P1Y" {
+		t.Fatalf("Unexpected data collection policy: %+v\n", dcp)
+	}
+	if strings.Join(dcp.Statements[0].Recipient, ", ") != "ours (Registry and its subcontractors), same" {
+		t.Errorf("Recipient description was not parsed: %v\n", dcp.Statements[0].Recipient)
+	}
+	if strings.Join(dcp.Statements[1].Purpose, " ") != "admin contact" {
+		t.Errorf("Unexpected purpose in second statement: %v\n", dcp.Statements[1].Purpose)
+	}
+}
+
+func BenchmarkClient_Hello(b *testing.B) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12001)
+	if err != nil {
+		b.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.Connect(); err != nil {
+		b.Fatalf("Connecting failed: %v\n", err)
+	}
+	defer eppTestClient.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		eppTestServer.SetupNewResponses(helloReq, greeting, failedCommand)
+
+		if _, err = eppTestClient.Hello(); err != nil {
+			b.Fatalf("Hello failed: %v\n", err)
+		}
+	}
+}
+
+var greetingWithPolicyDetails = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+<greeting>
+  <svID>Ficora EPP Server</svID>
+  <svDate>2020-06-20T23:59:59.9720308+02:00</svDate>
+  <svcMenu>
+    <version>1.0</version>
+    <lang>en</lang>
+    <objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+  </svcMenu>
+  <dcp>
+    <access>
+      <all />
+    </access>
+    <statement>
+      <purpose>
+        <prov />
+      </purpose>
+      <recipient>
+        <ours>
+          <recDesc>Registry and its subcontractors</recDesc>
+        </ours>
+        <same />
+      </recipient>
+      <retention>
+        <legal />
+      </retention>
+    </statement>
+    <statement>
+      <purpose>
+        <admin />
+        <contact />
+      </purpose>
+      <recipient>
+        <public />
+      </recipient>
+      <retention>
+        <business />
+      </retention>
+    </statement>
+    <expiry>
+      <relative>P1Y</relative>
+    </expiry>
+  </dcp>
+</greeting>
+</epp>`
+
 var helloReq = `<?xml version="1.0" encoding="UTF-8"?>
 <epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
   <hello></hello>
@@ -41,4 +253,17 @@ var failedCommand = `<?xml version="1.0" encoding="utf-8"?>
       <svTRID>sgi4sx2</svTRID>
     </trID>
   </response>
-</epp>`
\ No newline at end of file
+</epp>`
+
+var sessionTerminated = `<?xml version="1.0" encoding="utf-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <response>
+    <result code="2500">
+      <msg>Command failed; server closing connection</msg>
+    </result>
+    <trID>
+      <clTRID>REPLACE_REQ_ID</clTRID>
+      <svTRID>x2kd8se</svTRID>
+    </trID>
+  </response>
+</epp>`
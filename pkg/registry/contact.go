@@ -1,13 +1,17 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
-	"github.com/pkg/errors"
 )
 
 func (s *Client) CheckContacts(contacts ...string) ([]epp.ItemCheck, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.CheckContactsContext(context.Background(), contacts...)
+}
+
+func (s *Client) CheckContactsContext(ctx context.Context, contacts ...string) ([]epp.ItemCheck, error) {
+	reqID := s.newTransactionID()
 
 	contactCheck := epp.APIContactCheck{}
 	contactCheck.Xmlns = epp.EPPNamespace
@@ -21,9 +25,8 @@ func (s *Client) CheckContacts(contacts ...string) ([]epp.ItemCheck, error) {
 		return []epp.ItemCheck{}, err
 	}
 
-	checkRawResp, err := s.Send(checkData)
+	checkRawResp, err := s.SendContext(ctx, checkData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return []epp.ItemCheck{}, err
 	}
 
@@ -32,8 +35,8 @@ func (s *Client) CheckContacts(contacts ...string) ([]epp.ItemCheck, error) {
 		return []epp.ItemCheck{}, err
 	}
 
-	if checkResult.Response.Result.Code != 1000 {
-		return []epp.ItemCheck{}, errors.New("Request failed: " + checkResult.Response.Result.Msg)
+	if !checkResult.Response.Results.Code().IsSuccess() {
+		return []epp.ItemCheck{}, newEPPError(checkResult.Response.Results, checkResult.Response.TrID)
 	}
 
 	var checkItems []epp.ItemCheck
@@ -49,7 +52,11 @@ func (s *Client) CheckContacts(contacts ...string) ([]epp.ItemCheck, error) {
 }
 
 func (s *Client) CreateContact(contact epp.ContactInfo) (string, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.CreateContactContext(context.Background(), contact)
+}
+
+func (s *Client) CreateContactContext(ctx context.Context, contact epp.ContactInfo) (string, error) {
+	reqID := s.newTransactionID()
 
 	if err := contact.Validate(); err != nil {
 		return "", err
@@ -67,9 +74,8 @@ func (s *Client) CreateContact(contact epp.ContactInfo) (string, error) {
 		return "", err
 	}
 
-	createRawResp, err := s.Send(createData)
+	createRawResp, err := s.SendContext(ctx, createData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return "", err
 	}
 
@@ -78,18 +84,22 @@ func (s *Client) CreateContact(contact epp.ContactInfo) (string, error) {
 		return "", err
 	}
 
-	if createResult.Response.Result.Code != 1000 {
-		return "", errors.New("Request failed: " + createResult.Response.Result.Msg)
+	if !createResult.Response.Results.Code().IsSuccess() {
+		return "", newEPPError(createResult.Response.Results, createResult.Response.TrID)
 	}
 
 	contactID := createResult.Response.ResData.CreateData.ID
-	s.log.Info("Successfully created a new contact.", "contactID", contactID, "reqId", reqID)
+	s.log.Info("Successfully created a new contact.", "contactID", contactID, "clTRID", reqID)
 
 	return contactID, nil
 }
 
 func (s *Client) GetContact(contactId string) (epp.ContactResponse, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.GetContactContext(context.Background(), contactId)
+}
+
+func (s *Client) GetContactContext(ctx context.Context, contactId string) (epp.ContactResponse, error) {
+	reqID := s.newTransactionID()
 
 	contactInfo := epp.APIContactInfo{}
 	contactInfo.Xmlns = epp.EPPNamespace
@@ -103,9 +113,8 @@ func (s *Client) GetContact(contactId string) (epp.ContactResponse, error) {
 		return epp.ContactResponse{}, err
 	}
 
-	infoRawResp, err := s.Send(checkData)
+	infoRawResp, err := s.SendContext(ctx, checkData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.ContactResponse{}, err
 	}
 
@@ -114,15 +123,19 @@ func (s *Client) GetContact(contactId string) (epp.ContactResponse, error) {
 		return epp.ContactResponse{}, err
 	}
 
-	if infoResp.Response.Result.Code != 1000 {
-		return epp.ContactResponse{}, errors.New("Request failed: " + infoResp.Response.Result.Msg)
+	if !infoResp.Response.Results.Code().IsSuccess() {
+		return epp.ContactResponse{}, newEPPError(infoResp.Response.Results, infoResp.Response.TrID)
 	}
 
 	return infoResp.Response.ResData.ContactInfo, nil
 }
 
 func (s *Client) UpdateContact(contactID string, contact epp.ContactInfo) error {
-	reqID := createRequestID(reqIDLength)
+	return s.UpdateContactContext(context.Background(), contactID, contact)
+}
+
+func (s *Client) UpdateContactContext(ctx context.Context, contactID string, contact epp.ContactInfo) error {
+	reqID := s.newTransactionID()
 
 	if err := contact.Validate(); err != nil {
 		return err
@@ -141,9 +154,8 @@ func (s *Client) UpdateContact(contactID string, contact epp.ContactInfo) error
 		return err
 	}
 
-	updateRawResp, err := s.Send(updateData)
+	updateRawResp, err := s.SendContext(ctx, updateData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -152,17 +164,21 @@ func (s *Client) UpdateContact(contactID string, contact epp.ContactInfo) error
 		return err
 	}
 
-	if updateResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + updateResp.Response.Result.Msg)
+	if !updateResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(updateResp.Response.Results, updateResp.Response.TrID)
 	}
 
-	s.log.Info("Successfully updated contact.", "contactID", contactID, "reqID", reqID)
+	s.log.Info("Successfully updated contact.", "contactID", contactID, "clTRID", reqID)
 
 	return nil
 }
 
 func (s *Client) DeleteContact(contactID string) error {
-	reqID := createRequestID(reqIDLength)
+	return s.DeleteContactContext(context.Background(), contactID)
+}
+
+func (s *Client) DeleteContactContext(ctx context.Context, contactID string) error {
+	reqID := s.newTransactionID()
 
 	contactDelete := epp.APIContactDeletion{}
 	contactDelete.Xmlns = epp.EPPNamespace
@@ -176,9 +192,8 @@ func (s *Client) DeleteContact(contactID string) error {
 		return err
 	}
 
-	deleteRawResp, err := s.Send(deleteData)
+	deleteRawResp, err := s.SendContext(ctx, deleteData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -187,11 +202,11 @@ func (s *Client) DeleteContact(contactID string) error {
 		return err
 	}
 
-	if deleteResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + deleteResp.Response.Result.Msg)
+	if !deleteResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(deleteResp.Response.Results, deleteResp.Response.TrID)
 	}
 
-	s.log.Info("Successfully deleted contact.", "contactID", contactID, "reqID", reqID)
+	s.log.Info("Successfully deleted contact.", "contactID", contactID, "clTRID", reqID)
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"errors"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
 	"testing"
 )
@@ -178,8 +179,13 @@ func TestClient_DeleteContact(t *testing.T) {
 		t.Fatalf("Connecting failed: %v\n", err)
 	}
 
-	if err = eppTestClient.DeleteContact("nope"); err == nil {
-		t.Errorf("Deleting nonexisting contact should have caused an error.")
+	if err = eppTestClient.DeleteContact("nope"); !errors.Is(err, ErrObjectDoesNotExist) {
+		t.Errorf("Deleting nonexisting contact should have caused an error, got: %v", err)
+	}
+
+	var eppErr *EPPError
+	if errors.As(err, &eppErr) && eppErr.SvTRID != "yckddik" {
+		t.Errorf("Unexpected server transaction ID: %s", eppErr.SvTRID)
 	}
 
 	eppTestServer.SetupNewResponses(expectedContactDeletion, successfulCommandResponse, contactNotFound)
@@ -416,7 +422,7 @@ var contactNotFound = `<?xml version="1.0" encoding="utf-8"?>
       </contact:infData>
     </resData>
     <trID>
-      <clTRID>STXF8</clTRID>
+      <clTRID>REPLACE_REQ_ID</clTRID>
       <svTRID>yckddik</svTRID>
     </trID>
   </response>
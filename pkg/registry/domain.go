@@ -1,13 +1,17 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
-	"github.com/pkg/errors"
 )
 
 func (s *Client) CheckDomains(domains ...string) ([]epp.ItemCheck, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.CheckDomainsContext(context.Background(), domains...)
+}
+
+func (s *Client) CheckDomainsContext(ctx context.Context, domains ...string) ([]epp.ItemCheck, error) {
+	reqID := s.newTransactionID()
 
 	domainCheck := epp.APIDomainCheck{}
 	domainCheck.Xmlns = epp.EPPNamespace
@@ -21,9 +25,8 @@ func (s *Client) CheckDomains(domains ...string) ([]epp.ItemCheck, error) {
 		return []epp.ItemCheck{}, err
 	}
 
-	checkRawResp, err := s.Send(checkData)
+	checkRawResp, err := s.SendContext(ctx, checkData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return []epp.ItemCheck{}, err
 	}
 
@@ -32,8 +35,8 @@ func (s *Client) CheckDomains(domains ...string) ([]epp.ItemCheck, error) {
 		return []epp.ItemCheck{}, err
 	}
 
-	if checkResult.Response.Result.Code != 1000 {
-		return []epp.ItemCheck{}, errors.New("Request failed: " + checkResult.Response.Result.Msg)
+	if !checkResult.Response.Results.Code().IsSuccess() {
+		return []epp.ItemCheck{}, newEPPError(checkResult.Response.Results, checkResult.Response.TrID)
 	}
 
 	var checkItems []epp.ItemCheck
@@ -49,7 +52,11 @@ func (s *Client) CheckDomains(domains ...string) ([]epp.ItemCheck, error) {
 }
 
 func (s *Client) CreateDomain(details epp.DomainDetails) (epp.CreateData, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.CreateDomainContext(context.Background(), details)
+}
+
+func (s *Client) CreateDomainContext(ctx context.Context, details epp.DomainDetails) (epp.CreateData, error) {
+	reqID := s.newTransactionID()
 
 	if err := details.Validate(); err != nil {
 		return epp.CreateData{}, err
@@ -67,9 +74,8 @@ func (s *Client) CreateDomain(details epp.DomainDetails) (epp.CreateData, error)
 		return epp.CreateData{}, err
 	}
 
-	createRawResp, err := s.Send(createData)
+	createRawResp, err := s.SendContext(ctx, createData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.CreateData{}, err
 	}
 
@@ -78,8 +84,8 @@ func (s *Client) CreateDomain(details epp.DomainDetails) (epp.CreateData, error)
 		return epp.CreateData{}, err
 	}
 
-	if createResult.Response.Result.Code != 1000 {
-		return epp.CreateData{}, errors.New("Request failed: " + createResult.Response.Result.Msg)
+	if !createResult.Response.Results.Code().IsSuccess() {
+		return epp.CreateData{}, newEPPError(createResult.Response.Results, createResult.Response.TrID)
 	}
 
 	createDataResp := createResult.Response.ResData.CreateData
@@ -96,7 +102,11 @@ func (s *Client) CreateDomain(details epp.DomainDetails) (epp.CreateData, error)
 }
 
 func (s *Client) GetDomain(domain string) (epp.DomainInfoResp, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.GetDomainContext(context.Background(), domain)
+}
+
+func (s *Client) GetDomainContext(ctx context.Context, domain string) (epp.DomainInfoResp, error) {
+	reqID := s.newTransactionID()
 
 	domainInfo := epp.APIDomainInfo{}
 	domainInfo.Xmlns = epp.EPPNamespace
@@ -111,9 +121,8 @@ func (s *Client) GetDomain(domain string) (epp.DomainInfoResp, error) {
 		return epp.DomainInfoResp{}, err
 	}
 
-	infoRawResp, err := s.Send(infoData)
+	infoRawResp, err := s.SendContext(ctx, infoData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.DomainInfoResp{}, err
 	}
 
@@ -122,8 +131,8 @@ func (s *Client) GetDomain(domain string) (epp.DomainInfoResp, error) {
 		return epp.DomainInfoResp{}, err
 	}
 
-	if infoResp.Response.Result.Code != 1000 {
-		return epp.DomainInfoResp{}, errors.New("Request failed: " + infoResp.Response.Result.Msg)
+	if !infoResp.Response.Results.Code().IsSuccess() {
+		return epp.DomainInfoResp{}, newEPPError(infoResp.Response.Results, infoResp.Response.TrID)
 	}
 
 	domInfo := infoResp.Response.ResData.DomainInfo
@@ -157,7 +166,11 @@ func (s *Client) GetDomain(domain string) (epp.DomainInfoResp, error) {
 }
 
 func (s *Client) UpdateDomain(update epp.DomainUpdate) error {
-	reqID := createRequestID(reqIDLength)
+	return s.UpdateDomainContext(context.Background(), update)
+}
+
+func (s *Client) UpdateDomainContext(ctx context.Context, update epp.DomainUpdate) error {
+	reqID := s.newTransactionID()
 
 	domainUpdate := epp.APIDomainUpdate{}
 	domainUpdate.Xmlns = epp.EPPNamespace
@@ -170,9 +183,8 @@ func (s *Client) UpdateDomain(update epp.DomainUpdate) error {
 		return err
 	}
 
-	updateRawResp, err := s.Send(updateData)
+	updateRawResp, err := s.SendContext(ctx, updateData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -181,15 +193,23 @@ func (s *Client) UpdateDomain(update epp.DomainUpdate) error {
 		return err
 	}
 
-	if updateResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + updateResp.Response.Result.Msg)
+	if !updateResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(updateResp.Response.Results, updateResp.Response.TrID)
 	}
 
 	return nil
 }
 
 func (s *Client) UpdateDomainExtensions(domain string, extUpdate epp.DomainExtension) error {
-	reqID := createRequestID(reqIDLength)
+	return s.UpdateDomainExtensionsContext(context.Background(), domain, extUpdate)
+}
+
+func (s *Client) UpdateDomainExtensionsContext(ctx context.Context, domain string, extUpdate epp.DomainExtension) error {
+	if err := s.requireService(epp.SecDNSNamespace); err != nil {
+		return err
+	}
+
+	reqID := s.newTransactionID()
 
 	domainUpdate := epp.APIDomainUpdate{}
 	domainUpdate.Xmlns = epp.EPPNamespace
@@ -204,9 +224,8 @@ func (s *Client) UpdateDomainExtensions(domain string, extUpdate epp.DomainExten
 		return err
 	}
 
-	updateRawResp, err := s.Send(updateData)
+	updateRawResp, err := s.SendContext(ctx, updateData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -215,15 +234,19 @@ func (s *Client) UpdateDomainExtensions(domain string, extUpdate epp.DomainExten
 		return err
 	}
 
-	if updateResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + updateResp.Response.Result.Msg)
+	if !updateResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(updateResp.Response.Results, updateResp.Response.TrID)
 	}
 
 	return nil
 }
 
 func (s *Client) RenewDomain(domain, currentExpiration string, years int) (epp.RenewalData, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.RenewDomainContext(context.Background(), domain, currentExpiration, years)
+}
+
+func (s *Client) RenewDomainContext(ctx context.Context, domain, currentExpiration string, years int) (epp.RenewalData, error) {
+	reqID := s.newTransactionID()
 
 	domainRenewal := epp.APIDomainRenewal{}
 	domainRenewal.Xmlns = epp.EPPNamespace
@@ -240,9 +263,8 @@ func (s *Client) RenewDomain(domain, currentExpiration string, years int) (epp.R
 		return epp.RenewalData{}, err
 	}
 
-	renewRawResp, err := s.Send(renewalData)
+	renewRawResp, err := s.SendContext(ctx, renewalData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.RenewalData{}, err
 	}
 
@@ -251,8 +273,8 @@ func (s *Client) RenewDomain(domain, currentExpiration string, years int) (epp.R
 		return epp.RenewalData{}, err
 	}
 
-	if renewResp.Response.Result.Code != 1000 {
-		return epp.RenewalData{}, errors.New("Request failed: " + renewResp.Response.Result.Msg)
+	if !renewResp.Response.Results.Code().IsSuccess() {
+		return epp.RenewalData{}, newEPPError(renewResp.Response.Results, renewResp.Response.TrID)
 	}
 
 	renewalInfo := renewResp.Response.ResData.RenewalData
@@ -265,7 +287,11 @@ func (s *Client) RenewDomain(domain, currentExpiration string, years int) (epp.R
 }
 
 func (s *Client) TransferDomain(domain, transferKey string, newNameservers []string) (epp.TransferData, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.TransferDomainContext(context.Background(), domain, transferKey, newNameservers)
+}
+
+func (s *Client) TransferDomainContext(ctx context.Context, domain, transferKey string, newNameservers []string) (epp.TransferData, error) {
+	reqID := s.newTransactionID()
 
 	domainTransfer := epp.APIDomainTransfer{}
 	domainTransfer.Xmlns = epp.EPPNamespace
@@ -287,9 +313,8 @@ func (s *Client) TransferDomain(domain, transferKey string, newNameservers []str
 		return epp.TransferData{}, err
 	}
 
-	transferRawResp, err := s.Send(transferData)
+	transferRawResp, err := s.SendContext(ctx, transferData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.TransferData{}, err
 	}
 
@@ -298,8 +323,8 @@ func (s *Client) TransferDomain(domain, transferKey string, newNameservers []str
 		return epp.TransferData{}, err
 	}
 
-	if transferResp.Response.Result.Code != 1000 {
-		return epp.TransferData{}, errors.New("Request failed: " + transferResp.Response.Result.Msg)
+	if !transferResp.Response.Results.Code().IsSuccess() {
+		return epp.TransferData{}, newEPPError(transferResp.Response.Results, transferResp.Response.TrID)
 	}
 
 	transfer := transferResp.Response.ResData.TransferData
@@ -312,7 +337,11 @@ func (s *Client) TransferDomain(domain, transferKey string, newNameservers []str
 }
 
 func (s *Client) DeleteDomain(domain string) error {
-	reqID := createRequestID(reqIDLength)
+	return s.DeleteDomainContext(context.Background(), domain)
+}
+
+func (s *Client) DeleteDomainContext(ctx context.Context, domain string) error {
+	reqID := s.newTransactionID()
 
 	domainDeletion := epp.APIDomainDeletion{}
 	domainDeletion.Xmlns = epp.EPPNamespace
@@ -326,9 +355,8 @@ func (s *Client) DeleteDomain(domain string) error {
 		return err
 	}
 
-	deleteRawResp, err := s.Send(deleteData)
+	deleteRawResp, err := s.SendContext(ctx, deleteData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -337,8 +365,8 @@ func (s *Client) DeleteDomain(domain string) error {
 		return err
 	}
 
-	if deleteResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + deleteResp.Response.Result.Msg)
+	if !deleteResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(deleteResp.Response.Results, deleteResp.Response.TrID)
 	}
 
 	return nil
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"strings"
+)
+
+// EPPError is returned when the registry responds to a command with a result
+// code indicating failure. It can be compared to the sentinel errors below
+// with errors.Is, which matches errors by their result code.
+type EPPError struct {
+	Code    epp.ResultCode
+	Message string
+	// Values holds the elements that caused the failure, as raw XML.
+	Values  []string
+	Reasons []string
+	ClTRID  string
+	SvTRID  string
+}
+
+func (e *EPPError) Error() string {
+	msg := fmt.Sprintf("Request failed: %s (%d)", e.Message, e.Code)
+	if len(e.Reasons) > 0 {
+		msg += ": " + strings.Join(e.Reasons, ", ")
+	}
+
+	return msg
+}
+
+func (e *EPPError) Is(target error) bool {
+	t, ok := target.(*EPPError)
+	if !ok {
+		return false
+	}
+
+	return t.Code == e.Code
+}
+
+// Errors for the result codes defined in RFC 5730, to be used with errors.Is.
+var (
+	ErrUnknownCommand                      = resultError(epp.ResultUnknownCommand)
+	ErrCommandSyntaxError                  = resultError(epp.ResultCommandSyntaxError)
+	ErrCommandUseError                     = resultError(epp.ResultCommandUseError)
+	ErrRequiredParameterMissing            = resultError(epp.ResultRequiredParameterMissing)
+	ErrParameterValueRangeError            = resultError(epp.ResultParameterValueRangeError)
+	ErrParameterValueSyntaxError           = resultError(epp.ResultParameterValueSyntaxError)
+	ErrUnimplementedProtocolVersion        = resultError(epp.ResultUnimplementedProtocolVersion)
+	ErrUnimplementedCommand                = resultError(epp.ResultUnimplementedCommand)
+	ErrUnimplementedOption                 = resultError(epp.ResultUnimplementedOption)
+	ErrUnimplementedExtension              = resultError(epp.ResultUnimplementedExtension)
+	ErrBillingFailure                      = resultError(epp.ResultBillingFailure)
+	ErrObjectNotEligibleForRenewal         = resultError(epp.ResultObjectNotEligibleForRenewal)
+	ErrObjectNotEligibleForTransfer        = resultError(epp.ResultObjectNotEligibleForTransfer)
+	ErrAuthenticationError                 = resultError(epp.ResultAuthenticationError)
+	ErrAuthorizationError                  = resultError(epp.ResultAuthorizationError)
+	ErrInvalidAuthorizationInfo            = resultError(epp.ResultInvalidAuthorizationInfo)
+	ErrObjectPendingTransfer               = resultError(epp.ResultObjectPendingTransfer)
+	ErrObjectNotPendingTransfer            = resultError(epp.ResultObjectNotPendingTransfer)
+	ErrObjectExists                        = resultError(epp.ResultObjectExists)
+	ErrObjectDoesNotExist                  = resultError(epp.ResultObjectDoesNotExist)
+	ErrObjectStatusProhibitsOperation      = resultError(epp.ResultObjectStatusProhibitsOperation)
+	ErrObjectAssociationProhibitsOperation = resultError(epp.ResultObjectAssociationProhibitsOperation)
+	ErrParameterValuePolicyError           = resultError(epp.ResultParameterValuePolicyError)
+	ErrUnimplementedObjectService          = resultError(epp.ResultUnimplementedObjectService)
+	ErrDataManagementPolicyViolation       = resultError(epp.ResultDataManagementPolicyViolation)
+	ErrCommandFailed                       = resultError(epp.ResultCommandFailed)
+	ErrCommandFailedServerClosing          = resultError(epp.ResultCommandFailedServerClosing)
+	ErrAuthenticationErrorServerClosing    = resultError(epp.ResultAuthenticationErrorServerClosing)
+	ErrSessionLimitExceeded                = resultError(epp.ResultSessionLimitExceeded)
+)
+
+func resultError(code epp.ResultCode) *EPPError {
+	return &EPPError{Code: code, Message: code.String()}
+}
+
+// newEPPError creates an error from the primary result of a response,
+// collecting the failing values and reasons from all of its results.
+func newEPPError(results epp.Results, trID epp.Transaction) *EPPError {
+	result := results.Primary()
+	eppErr := EPPError{
+		Code:    result.Code,
+		Message: result.Msg,
+		ClTRID:  trID.ClTRID,
+		SvTRID:  trID.SvTRID,
+	}
+
+	for _, result = range results {
+		for _, value := range result.Values {
+			eppErr.Values = append(eppErr.Values, strings.TrimSpace(value.XML))
+		}
+		for _, extValue := range result.ExtValues {
+			eppErr.Values = append(eppErr.Values, strings.TrimSpace(extValue.Value.XML))
+			if extValue.Reason != "" {
+				eppErr.Reasons = append(eppErr.Reasons, extValue.Reason)
+			}
+		}
+	}
+
+	return &eppErr
+}
@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"encoding/xml"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+func TestEPPError(t *testing.T) {
+	var result epp.APIResult
+	if err := xml.Unmarshal([]byte(parameterPolicyError), &result); err != nil {
+		t.Fatalf("Unmarshalling result failed: %v\n", err)
+	}
+
+	err := errors.Wrap(newEPPError(result.Response.Results, result.Response.TrID), "Unable to update domain")
+
+	if !errors.Is(err, ErrParameterValuePolicyError) {
+		t.Errorf("Error should match its result code: %v\n", err)
+	}
+	if errors.Is(err, ErrObjectDoesNotExist) {
+		t.Errorf("Error should not match another result code: %v\n", err)
+	}
+
+	var eppErr *EPPError
+	if !errors.As(err, &eppErr) {
+		t.Fatalf("Error should be an EPPError: %v\n", err)
+	}
+
+	if eppErr.Code != 2306 || eppErr.Message != "Parameter value policy error" {
+		t.Errorf("Unexpected code or message: %d %s\n", eppErr.Code, eppErr.Message)
+	}
+	if eppErr.ClTRID != "ABCDE" || eppErr.SvTRID != "a7fg3kd" {
+		t.Errorf("Unexpected transaction IDs: %s %s\n", eppErr.ClTRID, eppErr.SvTRID)
+	}
+	if len(eppErr.Values) != 1 || eppErr.Values[0] != "<domain:hostObj>ns1.invalid</domain:hostObj>" {
+		t.Errorf("Unexpected values: %v\n", eppErr.Values)
+	}
+	if len(eppErr.Reasons) != 1 || eppErr.Reasons[0] != "Name server does not resolve" {
+		t.Errorf("Unexpected reasons: %v\n", eppErr.Reasons)
+	}
+
+	expectedMsg := "Unable to update domain: Request failed: Parameter value policy error (2306): Name server does not resolve"
+	if err.Error() != expectedMsg {
+		t.Errorf("Unexpected error message: %s\n", err.Error())
+	}
+}
+
+func TestMultipleResults(t *testing.T) {
+	var result epp.APIResult
+	if err := xml.Unmarshal([]byte(multipleResults), &result); err != nil {
+		t.Fatalf("Unmarshalling result failed: %v\n", err)
+	}
+
+	results := result.Response.Results
+	if len(results) != 2 {
+		t.Fatalf("Expected two results, got %d\n", len(results))
+	}
+	if results.Code() != epp.ResultParameterValueSyntaxError {
+		t.Errorf("Unexpected primary result code: %d\n", results.Code())
+	}
+	if results.Code().IsSuccess() || results.Code().IsRetryable() {
+		t.Errorf("Result code %d should be a permanent failure\n", results.Code())
+	}
+
+	eppErr := newEPPError(results, result.Response.TrID)
+	if !errors.Is(eppErr, ErrParameterValueSyntaxError) {
+		t.Errorf("Error should match the primary result code: %v\n", eppErr)
+	}
+	if len(eppErr.Values) != 2 || len(eppErr.Reasons) != 1 {
+		t.Errorf("Values and reasons of all results should be collected: %v %v\n", eppErr.Values, eppErr.Reasons)
+	}
+}
+
+func TestResultCodes(t *testing.T) {
+	if !epp.ResultActionPending.IsSuccess() || !epp.ResultActionPending.IsPending() {
+		t.Error("Pending action should be a successful, pending result.")
+	}
+	if epp.ResultCommandCompleted.IsPending() {
+		t.Error("Completed command should not be pending.")
+	}
+	if !epp.ResultSessionLimitExceeded.IsRetryable() || !epp.ResultSessionLimitExceeded.ClosesConnection() {
+		t.Error("Exceeded session limit should be retryable and close the connection.")
+	}
+	if epp.ResultAuthorizationError.IsRetryable() {
+		t.Error("Authorization error should not be retryable.")
+	}
+	if epp.ResultObjectExists.String() != "Object exists" {
+		t.Errorf("Unexpected message for result code: %s\n", epp.ResultObjectExists)
+	}
+}
+
+var multipleResults = `<?xml version="1.0" encoding="utf-8"?>
+<epp xmlns:domain="urn:ietf:params:xml:ns:domain-1.0" xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <response>
+    <result code="2005">
+      <msg>Parameter value syntax error</msg>
+      <value>
+        <domain:hostObj>ns1..example.fi</domain:hostObj>
+      </value>
+    </result>
+    <result code="2306">
+      <msg>Parameter value policy error</msg>
+      <extValue>
+        <value>
+          <domain:period unit="y">10</domain:period>
+        </value>
+        <reason>Period must be 1-5 years</reason>
+      </extValue>
+    </result>
+    <trID>
+      <clTRID>ABCDE</clTRID>
+      <svTRID>q8dk2la</svTRID>
+    </trID>
+  </response>
+</epp>`
+
+var parameterPolicyError = `<?xml version="1.0" encoding="utf-8"?>
+<epp xmlns:domain="urn:ietf:params:xml:ns:domain-1.0" xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <response>
+    <result code="2306">
+      <msg>Parameter value policy error</msg>
+      <extValue>
+        <value>
+          <domain:hostObj>ns1.invalid</domain:hostObj>
+        </value>
+        <reason>Name server does not resolve</reason>
+      </extValue>
+    </result>
+    <trID>
+      <clTRID>ABCDE</clTRID>
+      <svTRID>a7fg3kd</svTRID>
+    </trID>
+  </response>
+</epp>`
@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxFrameSize is the largest frame read from the server unless set
+// with WithMaxFrameSize. Responses of the FI registry are far smaller.
+const DefaultMaxFrameSize = 16 << 20
+
+const frameHeaderSize = 4
+
+type FrameErrorKind int
+
+const (
+	// FrameInvalidLength is a length header smaller than the header itself.
+	FrameInvalidLength FrameErrorKind = iota + 1
+	// FrameTooLarge is a length header exceeding the maximum frame size.
+	FrameTooLarge
+	// FrameTruncated is a connection closed before the whole frame was read.
+	FrameTruncated
+)
+
+// FrameError is returned when a frame received from the server cannot be
+// read. The connection is closed, as the stream can no longer be trusted. It
+// can be compared to the sentinel errors below with errors.Is, which matches
+// errors by their kind.
+type FrameError struct {
+	Kind FrameErrorKind
+	// Length is the frame length announced in the header, including the
+	// header itself, or zero if the header was not received completely.
+	Length int64
+	// Received is the amount of bytes of the frame received, header included.
+	Received int64
+	Limit    int64
+	Err      error
+}
+
+// Errors for frame error kinds, to be used with errors.Is.
+var (
+	ErrInvalidFrameLength = &FrameError{Kind: FrameInvalidLength}
+	ErrFrameTooLarge      = &FrameError{Kind: FrameTooLarge}
+	ErrFrameTruncated     = &FrameError{Kind: FrameTruncated}
+)
+
+func (e *FrameError) Error() string {
+	switch e.Kind {
+	case FrameInvalidLength:
+		return fmt.Sprintf("Invalid frame length %d", e.Length)
+	case FrameTooLarge:
+		return fmt.Sprintf("Frame of %d bytes exceeds the maximum frame size of %d bytes", e.Length, e.Limit)
+	case FrameTruncated:
+		if e.Length == 0 {
+			return fmt.Sprintf("Truncated frame: received %d of %d header bytes", e.Received, frameHeaderSize)
+		}
+		return fmt.Sprintf("Truncated frame: received %d of %d bytes", e.Received, e.Length)
+	}
+
+	return "Invalid frame"
+}
+
+func (e *FrameError) Is(target error) bool {
+	t, ok := target.(*FrameError)
+	if !ok {
+		return false
+	}
+
+	return t.Kind == e.Kind
+}
+
+func (e *FrameError) Unwrap() error {
+	return e.Err
+}
+
+// ReadFrame reads a frame of at most limit bytes, header included, and
+// returns its payload. Zero limit reads frames of any size. The payload
+// buffer grows as data arrives instead of being allocated by the announced
+// length. A connection closed cleanly before a new frame starts is reported
+// as io.EOF.
+func ReadFrame(r io.Reader, limit int64) ([]byte, error) {
+	header := make([]byte, frameHeaderSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, &FrameError{Kind: FrameTruncated, Received: int64(n), Err: err}
+		}
+		return nil, err
+	}
+
+	length := int64(binary.BigEndian.Uint32(header))
+	if length < frameHeaderSize {
+		return nil, &FrameError{Kind: FrameInvalidLength, Length: length, Received: frameHeaderSize}
+	}
+	if limit > 0 && length > limit {
+		return nil, &FrameError{Kind: FrameTooLarge, Length: length, Received: frameHeaderSize, Limit: limit}
+	}
+
+	var payload bytes.Buffer
+	n, err := io.CopyN(&payload, r, length-frameHeaderSize)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, &FrameError{Kind: FrameTruncated, Length: length, Received: frameHeaderSize + n, Err: err}
+		}
+		return nil, err
+	}
+
+	return payload.Bytes(), nil
+}
+
+// WriteFrame writes payload as a single frame, prefixed with its length.
+func WriteFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(frameHeaderSize+len(payload)))
+
+	_, err := w.Write(append(frame, payload...))
+	return err
+}
@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// newPipeClient returns a client connected to an in-memory server sending
+// data and closing the connection.
+func newPipeClient(maxFrameSize int64, data []byte) *Client {
+	transport := &PipeTransport{
+		Serve: func(conn net.Conn) {
+			_, _ = conn.Write(data)
+		},
+		MaxFrameSize: maxFrameSize,
+	}
+	_ = transport.Connect(context.Background())
+
+	return &Client{
+		transport:   transport,
+		connected:   true,
+		exchange:    make(chan struct{}, 1),
+		readTimeout: 5 * time.Second,
+		log:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func frame(length uint32, payload string) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, length), payload...)
+}
+
+func TestClient_ReadFrames(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+		err      error
+	}{
+		{"valid", frame(9, "hello"), "hello", nil},
+		{"empty", frame(4, ""), "", nil},
+		{"at limit", frame(64, string(make([]byte, 60))), string(make([]byte, 60)), nil},
+		{"header too small", frame(3, ""), "", ErrInvalidFrameLength},
+		{"zero length", frame(0, ""), "", ErrInvalidFrameLength},
+		{"over limit", frame(65, "hello"), "", ErrFrameTooLarge},
+		{"huge", frame(0xffffffff, "hello"), "", ErrFrameTooLarge},
+		{"truncated body", frame(20, "hello"), "", ErrFrameTruncated},
+		{"truncated header", []byte{0, 0}, "", ErrFrameTruncated},
+		{"closed", nil, "", io.EOF},
+	}
+
+	for _, test := range tests {
+		client := newPipeClient(64, test.data)
+
+		payload, err := client.Read()
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%s: reading frame failed: %v\n", test.name, err)
+			} else if string(payload) != test.expected {
+				t.Errorf("%s: unexpected payload %q\n", test.name, payload)
+			}
+			continue
+		}
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, got: %v\n", test.name, test.err, err)
+		}
+		if client.connected {
+			t.Errorf("%s: connection should have been closed after a failed read.\n", test.name)
+		}
+	}
+
+	var frameErr *FrameError
+	client := newPipeClient(64, frame(20, "hello"))
+	if _, err := client.Read(); !errors.As(err, &frameErr) || frameErr.Length != 20 || frameErr.Received != 9 {
+		t.Errorf("Truncated frame should report its length and received bytes, got: %v\n", err)
+	}
+}
+
+func FuzzClientRead(f *testing.F) {
+	f.Add(frame(9, "hello"))
+	f.Add(frame(4, ""))
+	f.Add(frame(3, ""))
+	f.Add(frame(20, "hello"))
+	f.Add(frame(0xffffffff, ""))
+	f.Add(frame(0x80000000, "hello"))
+	f.Add([]byte{0, 0, 0})
+	f.Add(append(frame(9, "hello"), frame(9, "world")...))
+
+	const limit = 1024
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		client := newPipeClient(limit, data)
+		defer client.transport.Close()
+
+		payload, err := client.Read()
+		if err != nil {
+			var frameErr *FrameError
+			if err != io.EOF && !errors.As(err, &frameErr) {
+				t.Fatalf("Unexpected error type %T: %v", err, err)
+			}
+			if client.connected {
+				t.Fatal("Connection should have been closed after a failed read.")
+			}
+			return
+		}
+
+		length := binary.BigEndian.Uint32(data)
+		if int64(length) > limit || len(payload) != int(length)-frameHeaderSize {
+			t.Fatalf("Frame of length %d returned %d bytes", length, len(payload))
+		}
+		if string(payload) != string(data[frameHeaderSize:length]) {
+			t.Fatal("Payload does not match the frame.")
+		}
+	})
+}
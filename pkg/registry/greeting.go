@@ -1,33 +1,34 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
 	"github.com/pkg/errors"
 )
 
 func (s *Client) Hello() (epp.Greeting, error) {
-	if s.conn != nil {
-		hello := epp.APIHello{
-			XMLName: xml.Name{},
-			Xmlns:   epp.EPPNamespace,
-		}
-		helloMsg, _ := xml.MarshalIndent(hello, "", "  ")
-		apiResp, err := s.Send(helloMsg)
-		if err != nil {
-			s.logAPIConnectionError(err)
-			return epp.Greeting{}, err
-		}
-
-		greeting, err := unmarshalGreeting(apiResp)
-		if err != nil {
-			return epp.Greeting{}, err
-		}
-
-		return greeting, nil
+	return s.HelloContext(context.Background())
+}
+
+func (s *Client) HelloContext(ctx context.Context) (epp.Greeting, error) {
+	hello := epp.APIHello{
+		XMLName: xml.Name{},
+		Xmlns:   epp.EPPNamespace,
+	}
+	helloMsg, _ := xml.MarshalIndent(hello, "", "  ")
+	apiResp, err := s.SendContext(ctx, helloMsg)
+	if err != nil {
+		return epp.Greeting{}, err
+	}
+
+	greeting, err := unmarshalGreeting(apiResp)
+	if err != nil {
+		return epp.Greeting{}, err
 	}
+	s.setGreeting(greeting)
 
-	return epp.Greeting{}, errors.New("Uninitialized connection, unable to connect to server.")
+	return greeting, nil
 }
 
 func unmarshalGreeting(rawGreeting []byte) (epp.Greeting, error) {
@@ -1,13 +1,17 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
-	"github.com/pkg/errors"
 )
 
 func (s *Client) CheckHosts(hosts ...string) ([]epp.ItemCheck, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.CheckHostsContext(context.Background(), hosts...)
+}
+
+func (s *Client) CheckHostsContext(ctx context.Context, hosts ...string) ([]epp.ItemCheck, error) {
+	reqID := s.newTransactionID()
 
 	hostCheck := epp.APIHostCheck{}
 	hostCheck.Xmlns = epp.EPPNamespace
@@ -21,9 +25,8 @@ func (s *Client) CheckHosts(hosts ...string) ([]epp.ItemCheck, error) {
 		return []epp.ItemCheck{}, err
 	}
 
-	checkRawResp, err := s.Send(checkData)
+	checkRawResp, err := s.SendContext(ctx, checkData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return []epp.ItemCheck{}, err
 	}
 
@@ -32,8 +35,8 @@ func (s *Client) CheckHosts(hosts ...string) ([]epp.ItemCheck, error) {
 		return []epp.ItemCheck{}, err
 	}
 
-	if checkResult.Response.Result.Code != 1000 {
-		return []epp.ItemCheck{}, errors.New("Request failed: " + checkResult.Response.Result.Msg)
+	if !checkResult.Response.Results.Code().IsSuccess() {
+		return []epp.ItemCheck{}, newEPPError(checkResult.Response.Results, checkResult.Response.TrID)
 	}
 
 	var checkItems []epp.ItemCheck
@@ -49,7 +52,11 @@ func (s *Client) CheckHosts(hosts ...string) ([]epp.ItemCheck, error) {
 }
 
 func (s *Client) CreateHost(hostname string, ipAddresses []string) (epp.CreateData, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.CreateHostContext(context.Background(), hostname, ipAddresses)
+}
+
+func (s *Client) CreateHostContext(ctx context.Context, hostname string, ipAddresses []string) (epp.CreateData, error) {
+	reqID := s.newTransactionID()
 
 	hostCreate := epp.APIHostCreation{}
 	hostCreate.Xmlns = epp.EPPNamespace
@@ -69,9 +76,8 @@ func (s *Client) CreateHost(hostname string, ipAddresses []string) (epp.CreateDa
 		return epp.CreateData{}, err
 	}
 
-	createRawResp, err := s.Send(createData)
+	createRawResp, err := s.SendContext(ctx, createData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.CreateData{}, err
 	}
 
@@ -80,8 +86,8 @@ func (s *Client) CreateHost(hostname string, ipAddresses []string) (epp.CreateDa
 		return epp.CreateData{}, err
 	}
 
-	if createResp.Response.Result.Code != 1000 {
-		return epp.CreateData{}, errors.New("Request failed: " + createResp.Response.Result.Msg)
+	if !createResp.Response.Results.Code().IsSuccess() {
+		return epp.CreateData{}, newEPPError(createResp.Response.Results, createResp.Response.TrID)
 	}
 
 	createInfo := createResp.Response.ResData.CreateData
@@ -95,7 +101,11 @@ func (s *Client) CreateHost(hostname string, ipAddresses []string) (epp.CreateDa
 }
 
 func (s *Client) GetHost(host string) (epp.HostInfoResp, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.GetHostContext(context.Background(), host)
+}
+
+func (s *Client) GetHostContext(ctx context.Context, host string) (epp.HostInfoResp, error) {
+	reqID := s.newTransactionID()
 
 	hostInfo := epp.APIHostInfo{}
 	hostInfo.Xmlns = epp.EPPNamespace
@@ -109,9 +119,8 @@ func (s *Client) GetHost(host string) (epp.HostInfoResp, error) {
 		return epp.HostInfoResp{}, err
 	}
 
-	infoRawResp, err := s.Send(infoData)
+	infoRawResp, err := s.SendContext(ctx, infoData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.HostInfoResp{}, err
 	}
 
@@ -120,8 +129,8 @@ func (s *Client) GetHost(host string) (epp.HostInfoResp, error) {
 		return epp.HostInfoResp{}, err
 	}
 
-	if infoResp.Response.Result.Code != 1000 {
-		return epp.HostInfoResp{}, errors.New("Request failed: " + infoResp.Response.Result.Msg)
+	if !infoResp.Response.Results.Code().IsSuccess() {
+		return epp.HostInfoResp{}, newEPPError(infoResp.Response.Results, infoResp.Response.TrID)
 	}
 
 	hostnameInfo := infoResp.Response.ResData.HostInfo
@@ -140,7 +149,11 @@ func (s *Client) GetHost(host string) (epp.HostInfoResp, error) {
 }
 
 func (s *Client) UpdateHost(hostname string, addIPs, removeIPs []string) error {
-	reqID := createRequestID(reqIDLength)
+	return s.UpdateHostContext(context.Background(), hostname, addIPs, removeIPs)
+}
+
+func (s *Client) UpdateHostContext(ctx context.Context, hostname string, addIPs, removeIPs []string) error {
+	reqID := s.newTransactionID()
 
 	hostUpdate := epp.APIHostUpdate{}
 	hostUpdate.Xmlns = epp.EPPNamespace
@@ -166,9 +179,8 @@ func (s *Client) UpdateHost(hostname string, addIPs, removeIPs []string) error {
 		return err
 	}
 
-	updateRawResp, err := s.Send(updateData)
+	updateRawResp, err := s.SendContext(ctx, updateData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -177,15 +189,19 @@ func (s *Client) UpdateHost(hostname string, addIPs, removeIPs []string) error {
 		return err
 	}
 
-	if updateResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + updateResp.Response.Result.Msg)
+	if !updateResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(updateResp.Response.Results, updateResp.Response.TrID)
 	}
 
 	return nil
 }
 
 func (s *Client) DeleteHost(hostname string) error {
-	reqID := createRequestID(reqIDLength)
+	return s.DeleteHostContext(context.Background(), hostname)
+}
+
+func (s *Client) DeleteHostContext(ctx context.Context, hostname string) error {
+	reqID := s.newTransactionID()
 
 	hostDelete := epp.APIHostDeletion{}
 	hostDelete.Xmlns = epp.EPPNamespace
@@ -199,9 +215,8 @@ func (s *Client) DeleteHost(hostname string) error {
 		return err
 	}
 
-	deleteRawResp, err := s.Send(deleteData)
+	deleteRawResp, err := s.SendContext(ctx, deleteData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return err
 	}
 
@@ -210,8 +225,8 @@ func (s *Client) DeleteHost(hostname string) error {
 		return err
 	}
 
-	if deleteResp.Response.Result.Code != 1000 {
-		return errors.New("Request failed: " + deleteResp.Response.Result.Msg)
+	if !deleteResp.Response.Results.Code().IsSuccess() {
+		return newEPPError(deleteResp.Response.Results, deleteResp.Response.TrID)
 	}
 
 	return nil
@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// StartKeepalive keeps the session open by sending hello to the server
+// whenever no other command has been sent within the interval. Greeting is
+// refreshed from each response. Failures are reported to onError, which may
+// be nil, so the owner of the client can reconnect. The keepalive keeps on
+// running after failures until StopKeepalive or Close is called, so onError
+// is called once per interval while a failure persists. Calls are made one
+// at a time and in order from a goroutine of their own, so onError may call
+// Close or StopKeepalive, and it may still be running after they return.
+func (s *Client) StartKeepalive(interval time.Duration, onError func(error)) error {
+	if interval <= 0 {
+		return errors.New("Keepalive interval must be positive.")
+	}
+
+	s.keepaliveMu.Lock()
+	defer s.keepaliveMu.Unlock()
+
+	if s.keepaliveCancel != nil {
+		return errors.New("Keepalive is already running.")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.keepaliveCancel = cancel
+	s.keepaliveDone = done
+
+	go func() {
+		defer close(done)
+		s.keepalive(ctx, interval, onError)
+	}()
+
+	return nil
+}
+
+// StopKeepalive stops a keepalive started with StartKeepalive and waits for
+// it to finish.
+func (s *Client) StopKeepalive() {
+	s.keepaliveMu.Lock()
+	defer s.keepaliveMu.Unlock()
+
+	if s.keepaliveCancel == nil {
+		return
+	}
+
+	s.keepaliveCancel()
+	<-s.keepaliveDone
+
+	s.keepaliveCancel = nil
+	s.keepaliveDone = nil
+}
+
+func (s *Client) keepalive(ctx context.Context, interval time.Duration, onError func(error)) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	// Failures are reported by a goroutine of their own, so that onError can
+	// stop the keepalive waiting for this one to return.
+	var failures chan error
+	if onError != nil {
+		failures = make(chan error, 1)
+		defer close(failures)
+		go func() {
+			for err := range failures {
+				onError(err)
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		idle := time.Since(time.Unix(0, s.lastSent.Load()))
+		if idle < interval {
+			timer.Reset(interval - idle)
+			continue
+		}
+
+		if _, err := s.HelloContext(ctx); err != nil && ctx.Err() == nil {
+			s.log.Warn("Keepalive failed.", "error", err)
+			if failures != nil {
+				select {
+				case failures <- err:
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		timer.Reset(interval)
+	}
+}
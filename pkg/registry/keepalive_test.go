@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Keepalive(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12008)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	refreshedGreeting := strings.Replace(greeting, "Ficora EPP Server", "Refreshed EPP Server", 1)
+	for i := 0; i < 100; i++ {
+		eppTestServer.SetupNewResponses(helloReq, refreshedGreeting, failedCommand)
+	}
+
+	failures := make(chan error, 100)
+	err = eppTestClient.StartKeepalive(10*time.Millisecond, func(err error) {
+		failures <- err
+	})
+	if err != nil {
+		t.Fatalf("Starting keepalive failed: %v\n", err)
+	}
+
+	if err = eppTestClient.StartKeepalive(10*time.Millisecond, nil); err == nil {
+		t.Error("Starting a second keepalive should have failed.")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for eppTestClient.ServerGreeting().SvID != "Refreshed EPP Server" {
+		if time.Now().After(deadline) {
+			t.Fatal("Greeting was not refreshed by keepalive.")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Losing the connection is reported to the owner of the client.
+	_ = eppTestClient.acquire(context.Background())
+	_ = eppTestClient.abortConnection(context.Background(), nil)
+	eppTestClient.release()
+
+	select {
+	case err = <-failures:
+		if err != ErrNotConnected {
+			t.Errorf("Unexpected keepalive failure: %v\n", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Keepalive failure was not reported.")
+	}
+
+	eppTestClient.StopKeepalive()
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
+func TestClient_KeepaliveCloseOnError(t *testing.T) {
+	var connections atomic.Int32
+	client, err := New(
+		WithCredentials("test", "test123"),
+		WithTransport(&PipeTransport{Serve: servePipe(1, &connections)}),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	// The server drops the connection on the second hello, and the owner
+	// closes the client from the callback.
+	closed := make(chan error, 1)
+	err = client.StartKeepalive(10*time.Millisecond, func(error) {
+		closed <- client.Close()
+	})
+	if err != nil {
+		t.Fatalf("Starting keepalive failed: %v\n", err)
+	}
+
+	select {
+	case err = <-closed:
+		if err != nil {
+			t.Errorf("Closing from the callback failed: %v\n", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Closing the client from the keepalive callback did not return.")
+	}
+}
+
+func TestClient_KeepalivePersistentFailure(t *testing.T) {
+	var connections atomic.Int32
+	client, err := New(
+		WithCredentials("test", "test123"),
+		WithTransport(&PipeTransport{Serve: servePipe(1, &connections)}),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	defer client.Close()
+
+	// The connection is lost on the second hello and every hello after it
+	// fails, each reported once and never concurrently.
+	var calls, running, overlapping atomic.Int32
+	interval := 20 * time.Millisecond
+	start := time.Now()
+	err = client.StartKeepalive(interval, func(error) {
+		if running.Add(1) > 1 {
+			overlapping.Add(1)
+		}
+		calls.Add(1)
+		time.Sleep(interval / 2)
+		running.Add(-1)
+	})
+	if err != nil {
+		t.Fatalf("Starting keepalive failed: %v\n", err)
+	}
+
+	time.Sleep(10 * interval)
+	client.StopKeepalive()
+	elapsed := time.Since(start)
+
+	maxCalls := int32(elapsed/interval) + 1
+	if n := calls.Load(); n < 2 || n > maxCalls {
+		t.Errorf("Expected a failure to be reported once per interval, got %d calls in %s\n", n, elapsed)
+	}
+	if overlapping.Load() > 0 {
+		t.Error("Failures should have been reported one at a time.")
+	}
+}
@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/pkg/errors"
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
+	"strings"
+)
+
+// ErrPassphraseRequired is returned when loading an encrypted key without a
+// passphrase.
+var ErrPassphraseRequired = errors.New("Private key is encrypted, passphrase is required.")
+
+// IsEncryptedKey reports whether a PEM encoded private key is protected with
+// a passphrase, either as PKCS#8 or with legacy OpenSSL encryption.
+func IsEncryptedKey(keyPEM []byte) bool {
+	block := findKeyBlock(keyPEM)
+	if block == nil {
+		return false
+	}
+
+	// Legacy encryption is deprecated as insecure, but still common for RSA keys.
+	return block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+}
+
+// LoadKeyPair parses a PEM encoded certificate and private key, decrypting the
+// key with passphrase if it is encrypted. Both PKCS#8 encrypted keys
+// ("ENCRYPTED PRIVATE KEY") and legacy OpenSSL encrypted keys
+// ("Proc-Type: 4,ENCRYPTED") are supported.
+func LoadKeyPair(certPEM, keyPEM []byte, passphrase string) (tls.Certificate, error) {
+	if !IsEncryptedKey(keyPEM) {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return tls.Certificate{}, errors.Wrap(err, "Invalid client certificate or key")
+		}
+		return cert, nil
+	}
+	if passphrase == "" {
+		return tls.Certificate{}, ErrPassphraseRequired
+	}
+
+	block := findKeyBlock(keyPEM)
+	var key interface{}
+	var err error
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		key, err = pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(passphrase))
+	} else {
+		key, err = decryptLegacyKey(block, passphrase)
+	}
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "Unable to decrypt private key")
+	}
+
+	// The decrypted key is only kept in memory, re-encoded for tls.X509KeyPair
+	// which checks that it matches the certificate.
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "Unsupported private key")
+	}
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "Invalid client certificate or key")
+	}
+
+	return cert, nil
+}
+
+// LoadPKCS12 parses a PKCS#12 (.p12 or .pfx) bundle holding the client
+// certificate, its private key and optionally intermediate certificates,
+// which are sent to the server along with the client certificate.
+func LoadPKCS12(bundle []byte, passphrase string) (tls.Certificate, error) {
+	key, leaf, caCerts, err := pkcs12.DecodeChain(bundle, passphrase)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			if passphrase == "" {
+				return tls.Certificate{}, ErrPassphraseRequired
+			}
+			return tls.Certificate{}, errors.New("Incorrect passphrase for PKCS#12 bundle.")
+		}
+		return tls.Certificate{}, errors.Wrap(err, "Invalid PKCS#12 bundle")
+	}
+
+	// A key not matching the certificate fails here, as tls.X509KeyPair does
+	// for PEM, rather than in the handshake.
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return tls.Certificate{}, errors.New("Unsupported private key in PKCS#12 bundle.")
+	}
+	if pub, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(signer.Public()) {
+		return tls.Certificate{}, errors.New("Private key in PKCS#12 bundle does not match its certificate.")
+	}
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for _, ca := range caCerts {
+		cert.Certificate = append(cert.Certificate, ca.Raw)
+	}
+
+	return cert, nil
+}
+
+func decryptLegacyKey(block *pem.Block, passphrase string) (interface{}, error) {
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	}
+
+	return x509.ParsePKCS8PrivateKey(der)
+}
+
+func findKeyBlock(keyPEM []byte) *pem.Block {
+	for {
+		var block *pem.Block
+		block, keyPEM = pem.Decode(keyPEM)
+		if block == nil {
+			return nil
+		}
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return block
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/pkg/errors"
+	"github.com/youmark/pkcs8"
+	"io/ioutil"
+	"software.sslmate.com/src/go-pkcs12"
+	"testing"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+func TestClient_EncryptedCredentials(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12015)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+	clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+	caCert, _ := ioutil.ReadFile("../../testtmp/rootCA.crt")
+
+	plain, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatalf("Loading test certificate failed: %v\n", err)
+	}
+	leaf, _ := x509.ParseCertificate(plain.Certificate[0])
+
+	encryptedDER, err := pkcs8.MarshalPrivateKey(plain.PrivateKey, []byte(testPassphrase), nil)
+	if err != nil {
+		t.Fatalf("Encrypting test key failed: %v\n", err)
+	}
+	encryptedKey := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encryptedDER})
+
+	keyBlock, _ := pem.Decode(clientKey)
+	legacyBlock, err := x509.EncryptPEMBlock(rand.Reader, "PRIVATE KEY", keyBlock.Bytes, []byte(testPassphrase), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatalf("Encrypting test key failed: %v\n", err)
+	}
+	legacyKey := pem.EncodeToMemory(legacyBlock)
+
+	bundle, err := pkcs12.Modern2023.Encode(plain.PrivateKey, leaf, nil, testPassphrase)
+	if err != nil {
+		t.Fatalf("Creating PKCS#12 bundle failed: %v\n", err)
+	}
+
+	if IsEncryptedKey(clientKey) || !IsEncryptedKey(encryptedKey) || !IsEncryptedKey(legacyKey) {
+		t.Error("Encrypted keys were not detected correctly.")
+	}
+
+	credentials := map[string]Option{
+		"PKCS#8":      WithEncryptedClientCertificate(clientCert, encryptedKey, testPassphrase),
+		"legacy":      WithEncryptedClientCertificate(clientCert, legacyKey, testPassphrase),
+		"unencrypted": WithEncryptedClientCertificate(clientCert, clientKey, ""),
+		"PKCS#12":     WithPKCS12(bundle, testPassphrase),
+	}
+	for name, credential := range credentials {
+		client, err := New(
+			WithServer("127.0.0.1", 12015),
+			WithCredentials("test", "test123"),
+			WithCACertificates(caCert),
+			credential,
+		)
+		if err != nil {
+			t.Errorf("%s: creating client failed: %v\n", name, err)
+			continue
+		}
+
+		if err = client.Connect(); err != nil {
+			t.Errorf("%s: connecting failed: %v\n", name, err)
+			continue
+		}
+		if err = client.Close(); err != nil {
+			t.Errorf("%s: closing the connection failed: %v\n", name, err)
+		}
+	}
+
+	if _, err = LoadKeyPair(clientCert, encryptedKey, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Loading an encrypted key without passphrase should have required one, got: %v\n", err)
+	}
+	if _, err = LoadPKCS12(bundle, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Loading a bundle without passphrase should have required one, got: %v\n", err)
+	}
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Generating key failed: %v\n", err)
+	}
+	mismatched, err := pkcs12.Modern2023.Encode(otherKey, leaf, nil, testPassphrase)
+	if err != nil {
+		t.Fatalf("Creating PKCS#12 bundle failed: %v\n", err)
+	}
+	if _, err = LoadPKCS12(mismatched, testPassphrase); err == nil {
+		t.Error("Loading a bundle with a key not matching its certificate should have failed.")
+	}
+
+	for name, load := range map[string]func() (tls.Certificate, error){
+		"PKCS#8":  func() (tls.Certificate, error) { return LoadKeyPair(clientCert, encryptedKey, "wrong") },
+		"legacy":  func() (tls.Certificate, error) { return LoadKeyPair(clientCert, legacyKey, "wrong") },
+		"PKCS#12": func() (tls.Certificate, error) { return LoadPKCS12(bundle, "wrong") },
+	} {
+		if _, err = load(); err == nil {
+			t.Errorf("%s: loading with a wrong passphrase should have failed.\n", name)
+		}
+	}
+}
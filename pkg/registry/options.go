@@ -0,0 +1,511 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+)
+
+// Option configures a Client created with New.
+type Option func(*clientConfig) error
+
+// Dialer opens the TCP connection to the registry, over which the client
+// establishes TLS. *net.Dialer is used by default.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+type clientConfig struct {
+	serverHost     string
+	serverPort     int
+	username       string
+	password       string
+	tlsConfig      *tls.Config
+	certificate    *tls.Certificate
+	reloader       *CertificateReloader
+	rootCAs        *x509.CertPool
+	minVersion     uint16
+	cipherSuites   []uint16
+	serverName     string
+	pins           []string
+	expiryWarn     time.Duration
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	sendWaitTime   time.Duration
+	maxFrameSize   int64
+	log            *slog.Logger
+	unsafeLog      bool
+	dialer         Dialer
+	transport      Transport
+	transcript     io.Writer
+	transcriptPath string
+	idGenerator    TransactionIDGenerator
+	retryPolicy    RetryPolicy
+}
+
+const defaultPort = 700
+const defaultTimeout = 60 * time.Second
+
+// New creates a client configured with the given options. The server, the
+// credentials and the client certificate are required, everything else
+// has defaults: port 700, timeouts of 60 seconds, system CA certificates
+// and no retries. With WithTransport, only the credentials are required.
+func New(opts ...Option) (*Client, error) {
+	config := clientConfig{
+		serverPort:   defaultPort,
+		readTimeout:  defaultTimeout,
+		writeTimeout: defaultTimeout,
+		dialer:       &net.Dialer{},
+		idGenerator:  RandomIDGenerator{},
+		expiryWarn:   DefaultExpiryWarning,
+		maxFrameSize: DefaultMaxFrameSize,
+	}
+
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.serverHost == "" && config.transport == nil {
+		return nil, errors.New("Registry server is required.")
+	}
+	if config.username == "" {
+		return nil, errors.New("Username is required.")
+	}
+
+	tlsConfig := &tls.Config{}
+	if config.tlsConfig != nil {
+		tlsConfig = config.tlsConfig.Clone()
+	}
+	if config.certificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*config.certificate}
+	}
+	if config.reloader != nil {
+		tlsConfig.Certificates = nil
+		tlsConfig.GetClientCertificate = config.reloader.GetClientCertificate
+	}
+	if config.rootCAs != nil {
+		tlsConfig.RootCAs = config.rootCAs
+	}
+	if config.minVersion != 0 {
+		tlsConfig.MinVersion = config.minVersion
+	}
+	if config.cipherSuites != nil {
+		tlsConfig.CipherSuites = config.cipherSuites
+	}
+	if config.serverName != "" {
+		tlsConfig.ServerName = config.serverName
+	}
+	if len(tlsConfig.Certificates) == 0 && tlsConfig.GetClientCertificate == nil && config.transport == nil {
+		return nil, errors.New("Client certificate is required.")
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = config.serverHost
+	}
+	if len(config.pins) > 0 {
+		tlsConfig.VerifyConnection = verifyPins(config.pins, tlsConfig.ServerName, tlsConfig.VerifyConnection)
+	}
+
+	if config.log == nil {
+		config.log = slog.Default()
+	}
+
+	transport := config.transport
+	if transport == nil {
+		transport = &TLSTransport{
+			Address:      net.JoinHostPort(config.serverHost, fmt.Sprint(config.serverPort)),
+			Config:       tlsConfig,
+			Dialer:       config.dialer,
+			MaxFrameSize: config.maxFrameSize,
+		}
+	}
+	var transcriptFile *os.File
+	if config.transcriptPath != "" {
+		file, err := os.OpenFile(config.transcriptPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to open transcript "+config.transcriptPath)
+		}
+		transcriptFile = file
+		config.transcript = file
+	}
+	if config.transcript != nil {
+		transport = NewRecordingTransport(transport, config.transcript)
+	}
+
+	client := Client{
+		transport:      transport,
+		tlsConfig:      tlsConfig,
+		readTimeout:    config.readTimeout,
+		writeTimeout:   config.writeTimeout,
+		sendWaitTime:   config.sendWaitTime,
+		retryPolicy:    config.retryPolicy,
+		idGenerator:    config.idGenerator,
+		exchange:       make(chan struct{}, 1),
+		log:            config.log,
+		unsafeLogging:  config.unsafeLog,
+		certReloader:   config.reloader,
+		transcriptFile: transcriptFile,
+	}
+	client.credentials = Credentials{
+		username: config.username,
+		password: config.password,
+	}
+
+	if len(tlsConfig.Certificates) > 0 {
+		leaf, err := leafCertificate(tlsConfig.Certificates[0])
+		if err != nil {
+			_ = client.closeTranscript()
+			return nil, err
+		}
+		client.clientCert = leaf
+	}
+	client.checkCertificateExpiry(config.expiryWarn)
+
+	return &client, nil
+}
+
+// WithServer sets the registry server, e.g. epp.domain.fi, and its port.
+func WithServer(host string, port int) Option {
+	return func(c *clientConfig) error {
+		if host == "" {
+			return errors.New("Server host must not be empty.")
+		}
+		if port <= 0 || port > 65535 {
+			return errors.Errorf("Invalid server port: %d", port)
+		}
+
+		c.serverHost = host
+		c.serverPort = port
+		return nil
+	}
+}
+
+func WithCredentials(username, password string) Option {
+	return func(c *clientConfig) error {
+		c.username = username
+		c.password = password
+		return nil
+	}
+}
+
+// WithClientCertificate sets the client certificate and its private key,
+// both PEM encoded.
+func WithClientCertificate(certPEM, keyPEM []byte) Option {
+	return func(c *clientConfig) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return errors.Wrap(err, "Invalid client certificate or key")
+		}
+
+		c.certificate = &cert
+		return nil
+	}
+}
+
+// WithEncryptedClientCertificate sets the client certificate and its
+// passphrase protected private key, both PEM encoded. See LoadKeyPair.
+func WithEncryptedClientCertificate(certPEM, keyPEM []byte, passphrase string) Option {
+	return func(c *clientConfig) error {
+		cert, err := LoadKeyPair(certPEM, keyPEM, passphrase)
+		if err != nil {
+			return err
+		}
+
+		c.certificate = &cert
+		return nil
+	}
+}
+
+// WithPKCS12 sets the client certificate and key from a PKCS#12 (.p12 or
+// .pfx) bundle. See LoadPKCS12.
+func WithPKCS12(bundle []byte, passphrase string) Option {
+	return func(c *clientConfig) error {
+		cert, err := LoadPKCS12(bundle, passphrase)
+		if err != nil {
+			return err
+		}
+
+		c.certificate = &cert
+		return nil
+	}
+}
+
+// WithCertificateReloader uses the certificate of r, taking renewed
+// certificates into use when reconnecting. It replaces a certificate given
+// with other options. Closing r is left to the caller.
+func WithCertificateReloader(r *CertificateReloader) Option {
+	return func(c *clientConfig) error {
+		if r == nil {
+			return errors.New("Certificate reloader must not be nil.")
+		}
+
+		c.reloader = r
+		return nil
+	}
+}
+
+// WithExpiryWarning sets how long before the client certificate expires a
+// warning is logged when creating the client. Zero disables the warning.
+func WithExpiryWarning(window time.Duration) Option {
+	return func(c *clientConfig) error {
+		if window < 0 {
+			return errors.New("Expiry warning window must not be negative.")
+		}
+
+		c.expiryWarn = window
+		return nil
+	}
+}
+
+// WithTLSConfig sets the base TLS configuration. Client certificate and CA
+// certificates given with their own options take precedence over the ones
+// in config, regardless of the order of the options.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *clientConfig) error {
+		if config == nil {
+			return errors.New("TLS config must not be nil.")
+		}
+
+		c.tlsConfig = config
+		return nil
+	}
+}
+
+// WithCACertificates sets the PEM encoded CA certificates used to verify the
+// server instead of the system ones.
+func WithCACertificates(caCerts []byte) Option {
+	return func(c *clientConfig) error {
+		roots := x509.NewCertPool()
+		if ok := roots.AppendCertsFromPEM(caCerts); !ok {
+			return errors.New("Unable to parse given CA certificates.")
+		}
+
+		c.rootCAs = roots
+		return nil
+	}
+}
+
+func WithCAPool(pool *x509.CertPool) Option {
+	return func(c *clientConfig) error {
+		if pool == nil {
+			return errors.New("CA pool must not be nil.")
+		}
+
+		c.rootCAs = pool
+		return nil
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version, e.g. tls.VersionTLS13.
+func WithMinTLSVersion(version uint16) Option {
+	return func(c *clientConfig) error {
+		if version < tls.VersionTLS10 || version > tls.VersionTLS13 {
+			return errors.Errorf("Unknown TLS version: %#x", version)
+		}
+
+		c.minVersion = version
+		return nil
+	}
+}
+
+// WithCipherSuites restricts the cipher suites used with TLS 1.2 and older.
+// TLS 1.3 suites are not configurable. See ParseCipherSuites for using names.
+func WithCipherSuites(suites ...uint16) Option {
+	return func(c *clientConfig) error {
+		if len(suites) == 0 {
+			return errors.New("At least one cipher suite is required.")
+		}
+
+		c.cipherSuites = suites
+		return nil
+	}
+}
+
+// WithServerName sets the name the server certificate is verified against
+// and which is sent with SNI, when it differs from the server host, e.g.
+// when connecting by an IP address.
+func WithServerName(name string) Option {
+	return func(c *clientConfig) error {
+		if name == "" {
+			return errors.New("Server name must not be empty.")
+		}
+
+		c.serverName = name
+		return nil
+	}
+}
+
+// WithPinnedKeys accepts the server only if its certificate, or one of the
+// CA certificates it was verified with, has one of the given public keys.
+// Pins are base64 encoded SHA-256 digests of the subject public key info
+// (see SPKIFingerprint), optionally prefixed with "sha256/". Include a pin
+// of a backup key to survive certificate renewals.
+func WithPinnedKeys(pins ...string) Option {
+	return func(c *clientConfig) error {
+		if len(pins) == 0 {
+			return errors.New("At least one pin is required.")
+		}
+
+		for _, pin := range pins {
+			parsed, err := parsePin(pin)
+			if err != nil {
+				return err
+			}
+			c.pins = append(c.pins, parsed)
+		}
+		return nil
+	}
+}
+
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(c *clientConfig) error {
+		if timeout <= 0 {
+			return errors.New("Read timeout must be positive.")
+		}
+
+		c.readTimeout = timeout
+		return nil
+	}
+}
+
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(c *clientConfig) error {
+		if timeout <= 0 {
+			return errors.New("Write timeout must be positive.")
+		}
+
+		c.writeTimeout = timeout
+		return nil
+	}
+}
+
+// WithSendWaitTime is the option for SetSendWaitTime.
+func WithSendWaitTime(wait time.Duration) Option {
+	return func(c *clientConfig) error {
+		if wait < 0 {
+			return errors.New("Send wait time must not be negative.")
+		}
+
+		c.sendWaitTime = wait
+		return nil
+	}
+}
+
+// WithMaxFrameSize sets the largest frame in bytes, length header included,
+// accepted from the server. Larger frames fail with ErrFrameTooLarge and
+// close the connection.
+func WithMaxFrameSize(size int64) Option {
+	return func(c *clientConfig) error {
+		if size <= frameHeaderSize {
+			return errors.Errorf("Maximum frame size must be larger than %d bytes.", frameHeaderSize)
+		}
+
+		c.maxFrameSize = size
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the client. Without it, slog.Default is used.
+// Each request is logged with its command, transaction IDs, result code and
+// duration, and the messages themselves on debug level with secrets masked.
+func WithLogger(log *slog.Logger) Option {
+	return func(c *clientConfig) error {
+		if log == nil {
+			return errors.New("Logger must not be nil.")
+		}
+
+		c.log = log
+		return nil
+	}
+}
+
+// WithUnsafeDebugLogging disables masking passwords, keys and personal data
+// in the messages logged on debug level. Only meant for local debugging, as
+// the logs will then contain the credentials of the account.
+func WithUnsafeDebugLogging() Option {
+	return func(c *clientConfig) error {
+		c.unsafeLog = true
+		return nil
+	}
+}
+
+// WithTransport replaces the default TLS transport, e.g. with PipeTransport
+// in tests. Server, TLS, dialer and frame size options have no effect then.
+func WithTransport(transport Transport) Option {
+	return func(c *clientConfig) error {
+		if transport == nil {
+			return errors.New("Transport must not be nil.")
+		}
+
+		c.transport = transport
+		return nil
+	}
+}
+
+// WithTranscript records the frames exchanged with the registry to w, with
+// secrets masked. The transcript can be replayed with ReplayTransport.
+func WithTranscript(w io.Writer) Option {
+	return func(c *clientConfig) error {
+		if w == nil {
+			return errors.New("Transcript writer must not be nil.")
+		}
+
+		c.transcript = w
+		return nil
+	}
+}
+
+// WithTranscriptFile records a transcript as WithTranscript, appending it to
+// the file at path. The file is created if needed, and closed by Close.
+func WithTranscriptFile(path string) Option {
+	return func(c *clientConfig) error {
+		if path == "" {
+			return errors.New("Transcript path must not be empty.")
+		}
+
+		c.transcriptPath = path
+		return nil
+	}
+}
+
+func WithDialer(dialer Dialer) Option {
+	return func(c *clientConfig) error {
+		if dialer == nil {
+			return errors.New("Dialer must not be nil.")
+		}
+
+		c.dialer = dialer
+		return nil
+	}
+}
+
+func WithTransactionIDGenerator(generator TransactionIDGenerator) Option {
+	return func(c *clientConfig) error {
+		if generator == nil {
+			return errors.New("Transaction ID generator is required.")
+		}
+
+		c.idGenerator = generator
+		return nil
+	}
+}
+
+// WithRetryPolicy enables restoring lost sessions, see RetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *clientConfig) error {
+		if policy.MaxRetries < 0 || policy.Delay < 0 {
+			return errors.New("Retry policy values must not be negative.")
+		}
+
+		c.retryPolicy = policy
+		return nil
+	}
+}
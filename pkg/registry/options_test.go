@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingDialer struct {
+	dials atomic.Int32
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials.Add(1)
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, address)
+}
+
+func TestNew(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12011)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+	clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+	caCert, _ := ioutil.ReadFile("../../testtmp/rootCA.crt")
+
+	counter, err := NewCounterIDGenerator("OPT")
+	if err != nil {
+		t.Fatalf("Creating counter generator failed: %v\n", err)
+	}
+
+	dialer := &countingDialer{}
+	client, err := New(
+		WithServer("127.0.0.1", 12011),
+		WithCredentials("test", "test123"),
+		WithClientCertificate(clientCert, clientKey),
+		WithCACertificates(caCert),
+		WithReadTimeout(5*time.Second),
+		WithWriteTimeout(5*time.Second),
+		WithDialer(dialer),
+		WithTransactionIDGenerator(counter),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1}),
+	)
+	if err != nil {
+		t.Fatalf("Creating client with options failed: %v\n", err)
+	}
+
+	if client.readTimeout != 5*time.Second || client.retryPolicy.MaxRetries != 1 {
+		t.Error("Options were not applied to the client.")
+	}
+	if id := client.newTransactionID(); id != "OPT1" {
+		t.Errorf("Transaction ID generator was not used, got: %s\n", id)
+	}
+
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	if dialer.dials.Load() != 1 {
+		t.Errorf("Connection should have been opened with the given dialer.")
+	}
+
+	if err = client.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+
+	invalid := map[string][]Option{
+		"without server":      {WithCredentials("test", "test123"), WithClientCertificate(clientCert, clientKey)},
+		"without username":    {WithServer("127.0.0.1", 12011), WithClientCertificate(clientCert, clientKey)},
+		"without certificate": {WithServer("127.0.0.1", 12011), WithCredentials("test", "test123")},
+		"with invalid port":   {WithServer("127.0.0.1", 0)},
+		"with invalid key":    {WithClientCertificate(clientCert, []byte("not a key"))},
+		"with zero timeout":   {WithReadTimeout(0)},
+		"with nil dialer":     {WithDialer(nil)},
+	}
+	for name, opts := range invalid {
+		if _, err = New(opts...); err == nil {
+			t.Errorf("Creating client %s should have failed.\n", name)
+		}
+	}
+}
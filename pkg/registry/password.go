@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"crypto/rand"
+	"github.com/pkg/errors"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+const (
+	PasswordMinLength = 8
+	PasswordMaxLength = 16
+)
+
+const (
+	passwordLower   = "abcdefghijklmnopqrstuvwxyz"
+	passwordUpper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordDigits  = "0123456789"
+	passwordSpecial = "!#%()*+,-./:;=?@[]_{}~"
+)
+
+// ValidatePassword checks that the password fulfills the complexity rules of
+// FI registry: 8-16 printable ASCII characters without whitespace, including
+// at least one lowercase and one uppercase letter, a number and a special
+// character.
+func ValidatePassword(password string) error {
+	if len(password) < PasswordMinLength || len(password) > PasswordMaxLength {
+		return errors.Errorf("Password must be %d-%d characters long.", PasswordMinLength, PasswordMaxLength)
+	}
+
+	var lower, upper, digit, special bool
+	for _, c := range password {
+		switch {
+		case c > unicode.MaxASCII || !unicode.IsPrint(c) || unicode.IsSpace(c):
+			return errors.New("Password may only contain printable ASCII characters without whitespace.")
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			special = true
+		}
+	}
+
+	var missing []string
+	if !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if !digit {
+		missing = append(missing, "a number")
+	}
+	if !special {
+		missing = append(missing, "a special character")
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("Password must contain %s.", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// GeneratePassword creates a random password of the maximum length that
+// fulfills the complexity rules checked by ValidatePassword.
+func GeneratePassword() (string, error) {
+	classes := []string{passwordLower, passwordUpper, passwordDigits, passwordSpecial}
+	all := strings.Join(classes, "")
+
+	password := make([]byte, 0, PasswordMaxLength)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < PasswordMaxLength {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Shuffle so that the required characters are not always first.
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", errors.Wrap(err, "Unable to generate password")
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, errors.Wrap(err, "Unable to generate password")
+	}
+
+	return chars[i.Int64()], nil
+}
@@ -0,0 +1,36 @@
+package registry
+
+import "testing"
+
+func TestValidatePassword(t *testing.T) {
+	valid := []string{"Abcdef1!", "N3w-Passw0rd", "x{Y}9_________zZ"}
+	for _, password := range valid {
+		if err := ValidatePassword(password); err != nil {
+			t.Errorf("Password %s should be valid: %v\n", password, err)
+		}
+	}
+
+	invalid := []string{"Ab1!", "Abcdefgh1!Abcdefg", "abcdefg1!", "ABCDEFG1!", "Abcdefgh!", "Abcdefgh1", "Abc def1!", "Äbcdefg1!"}
+	for _, password := range invalid {
+		if err := ValidatePassword(password); err == nil {
+			t.Errorf("Password %s should be invalid.\n", password)
+		}
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		password, err := GeneratePassword()
+		if err != nil {
+			t.Fatalf("Generating password failed: %v\n", err)
+		}
+		if err = ValidatePassword(password); err != nil {
+			t.Errorf("Generated password %s is invalid: %v\n", password, err)
+		}
+		if seen[password] {
+			t.Errorf("Password %s was generated twice.\n", password)
+		}
+		seen[password] = true
+	}
+}
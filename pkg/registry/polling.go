@@ -1,13 +1,18 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
 	"github.com/pkg/errors"
 )
 
 func (s *Client) Poll() (epp.PollMessage, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.PollContext(context.Background())
+}
+
+func (s *Client) PollContext(ctx context.Context) (epp.PollMessage, error) {
+	reqID := s.newTransactionID()
 
 	pollReq := epp.APIPoll{}
 	pollReq.Xmlns = epp.EPPNamespace
@@ -19,9 +24,8 @@ func (s *Client) Poll() (epp.PollMessage, error) {
 		return epp.PollMessage{}, err
 	}
 
-	pollRawResp, err := s.Send(pollData)
+	pollRawResp, err := s.SendContext(ctx, pollData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return epp.PollMessage{}, err
 	}
 
@@ -30,11 +34,11 @@ func (s *Client) Poll() (epp.PollMessage, error) {
 		return epp.PollMessage{}, err
 	}
 
-	if pollResp.Response.Result.Code == 1300 {
+	if pollResp.Response.Results.Code() == epp.ResultNoMessages {
 		return epp.PollMessage{}, errors.New("No new messages available.")
 	}
-	if pollResp.Response.Result.Code != 1301 {
-		return epp.PollMessage{}, errors.New("Request failed: " + pollResp.Response.Result.Msg)
+	if pollResp.Response.Results.Code() != epp.ResultAckToDequeue {
+		return epp.PollMessage{}, newEPPError(pollResp.Response.Results, pollResp.Response.TrID)
 	}
 
 	date, err := parseDate(pollResp.Response.MsgQ.RawQDate)
@@ -49,7 +53,11 @@ func (s *Client) Poll() (epp.PollMessage, error) {
 }
 
 func (s *Client) PollAck(id string) (int, error) {
-	reqID := createRequestID(reqIDLength)
+	return s.PollAckContext(context.Background(), id)
+}
+
+func (s *Client) PollAckContext(ctx context.Context, id string) (int, error) {
+	reqID := s.newTransactionID()
 
 	ackReq := epp.APIPoll{}
 	ackReq.Xmlns = epp.EPPNamespace
@@ -62,9 +70,8 @@ func (s *Client) PollAck(id string) (int, error) {
 		return -1, err
 	}
 
-	ackRawResp, err := s.Send(ackData)
+	ackRawResp, err := s.SendContext(ctx, ackData)
 	if err != nil {
-		s.logAPIConnectionError(err, "requestID", reqID)
 		return -1, err
 	}
 
@@ -73,8 +80,8 @@ func (s *Client) PollAck(id string) (int, error) {
 		return -1, err
 	}
 
-	if ackResp.Response.Result.Code != 1000 {
-		return -1, errors.New("Request failed: " + ackResp.Response.Result.Msg)
+	if !ackResp.Response.Results.Code().IsSuccess() {
+		return -1, newEPPError(ackResp.Response.Results, ackResp.Response.TrID)
 	}
 
 	if ackResp.Response.MsgQ.ID != id {
@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+var ErrPoolClosed = errors.New("Session pool has been closed.")
+
+// Pool keeps a fixed number of logged in sessions to the registry and hands
+// them out to concurrent callers. Sessions found broken are replaced with new
+// ones when they are next taken into use.
+type Pool struct {
+	newClient func() (*Client, error)
+	size      int
+
+	// Each slot holds either an idle session or nil, when the session
+	// needs to be (re)opened before use.
+	sessions chan *Client
+
+	// closed is closed by Close, waking up callers waiting in Get. Put
+	// checks it and returns sessions to the pool holding mu, so that no
+	// session is left in the pool after Close has emptied it.
+	closed chan struct{}
+	mu     sync.Mutex
+}
+
+// NewPool opens the given amount of sessions, each with its own connection
+// created by newClient. The amount should not exceed the number of concurrent
+// sessions the registry allows for the account.
+func NewPool(ctx context.Context, sessions int, newClient func() (*Client, error)) (*Pool, error) {
+	if sessions <= 0 {
+		return nil, errors.New("Session pool size must be a positive integer.")
+	}
+
+	pool := Pool{
+		newClient: newClient,
+		size:      sessions,
+		sessions:  make(chan *Client, sessions),
+		closed:    make(chan struct{}),
+	}
+
+	for i := 0; i < sessions; i++ {
+		client, err := pool.openSession(ctx)
+		if err != nil {
+			_ = pool.Close()
+			return nil, errors.Wrap(err, "Unable to open sessions for the pool")
+		}
+		pool.sessions <- client
+	}
+
+	return &pool, nil
+}
+
+// Size returns the maximum amount of sessions in the pool.
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Get waits for a free session and reserves it for the caller, who must
+// return it with Put once done.
+func (p *Pool) Get(ctx context.Context) (*Client, error) {
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	var client *Client
+	select {
+	case client = <-p.sessions:
+	case <-p.closed:
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// The pool may have been closed while the session was taken from it.
+	if p.isClosed() {
+		_ = closeSession(client)
+		return nil, ErrPoolClosed
+	}
+
+	if client != nil && client.isConnected() && client.IsLoggedIn() {
+		return client, nil
+	}
+
+	if client != nil {
+		_ = client.Close()
+	}
+
+	client, err := p.openSession(ctx)
+	if err != nil {
+		p.sessions <- nil
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Put returns a session taken with Get back to the pool.
+func (p *Pool) Put(client *Client) {
+	p.mu.Lock()
+	if !p.isClosed() {
+		p.sessions <- client
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	_ = closeSession(client)
+}
+
+// Do runs fn with a session from the pool.
+func (p *Pool) Do(ctx context.Context, fn func(*Client) error) error {
+	client, err := p.Get(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Put(client)
+
+	return fn(client)
+}
+
+// Close logs out and closes all idle sessions. Sessions in use are closed
+// when they are returned with Put, and callers waiting in Get receive
+// ErrPoolClosed.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.isClosed() {
+		p.mu.Unlock()
+		return nil
+	}
+	close(p.closed)
+	p.mu.Unlock()
+
+	var firstErr error
+	for {
+		select {
+		case client := <-p.sessions:
+			if err := closeSession(client); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		default:
+			return firstErr
+		}
+	}
+}
+
+func (p *Pool) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *Pool) openSession(ctx context.Context) (*Client, error) {
+	client, err := p.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.ConnectContext(ctx); err != nil {
+		_ = client.Close()
+		return nil, errors.Wrap(err, "Unable to connect")
+	}
+
+	if err = client.LoginContext(ctx); err != nil {
+		_ = client.Close()
+		return nil, errors.Wrap(err, "Unable to login")
+	}
+
+	return client, nil
+}
+
+func closeSession(client *Client) error {
+	if client == nil {
+		return nil
+	}
+
+	var logoutErr error
+	if client.isConnected() && client.IsLoggedIn() {
+		logoutErr = client.Logout()
+	}
+
+	if err := client.Close(); err != nil {
+		return err
+	}
+
+	return logoutErr
+}
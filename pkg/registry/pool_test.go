@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_ConcurrentSessions(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12007)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	sessions := 3
+	for i := 0; i < sessions; i++ {
+		eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+	}
+
+	pool, err := NewPool(context.Background(), sessions, newPoolTestClient)
+	if err != nil {
+		t.Fatalf("Creating a session pool failed: %v\n", err)
+	}
+
+	requests := 30
+	for i := 0; i < requests; i++ {
+		eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := pool.Do(context.Background(), func(client *Client) error {
+				_, err := client.GetDomain("testdomain2.fi")
+				return err
+			})
+			if err != nil {
+				t.Errorf("Fetching domain with a pooled session failed: %v\n", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < sessions; i++ {
+		eppTestServer.SetupNewResponses(expectedLogout, successfulLogout, failedLogout)
+	}
+
+	if err = pool.Close(); err != nil {
+		t.Errorf("Closing the pool failed: %v\n", err)
+	}
+
+	if _, err = pool.Get(context.Background()); err != ErrPoolClosed {
+		t.Errorf("Getting a session from a closed pool should have failed, got: %v\n", err)
+	}
+}
+
+func TestPool_LimitAndReplacement(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12007)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+
+	pool, err := NewPool(context.Background(), 1, newPoolTestClient)
+	if err != nil {
+		t.Fatalf("Creating a session pool failed: %v\n", err)
+	}
+
+	client, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Getting a session failed: %v\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = pool.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Getting more sessions than the pool has should have blocked, got: %v\n", err)
+	}
+
+	// Simulate a dropped connection, which should be replaced on next use.
+	_ = client.Close()
+	pool.Put(client)
+
+	eppTestServer.SetupNewResponses(expectedLogin, successfulLogin, failedLogin)
+
+	replacement, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Replacing a broken session failed: %v\n", err)
+	}
+	if replacement == client {
+		t.Error("Broken session should have been replaced.")
+	}
+	pool.Put(replacement)
+
+	eppTestServer.SetupNewResponses(expectedLogout, successfulLogout, failedLogout)
+
+	if err = pool.Close(); err != nil {
+		t.Errorf("Closing the pool failed: %v\n", err)
+	}
+}
+
+func TestPool_CloseWakesWaitingGet(t *testing.T) {
+	pool, err := NewPool(context.Background(), 1, newPipePoolClient(&atomic.Int32{}))
+	if err != nil {
+		t.Fatalf("Creating a session pool failed: %v\n", err)
+	}
+
+	client, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Getting a session failed: %v\n", err)
+	}
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := pool.Get(context.Background())
+		waiting <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err = pool.Close(); err != nil {
+		t.Errorf("Closing the pool failed: %v\n", err)
+	}
+
+	select {
+	case err = <-waiting:
+		if err != ErrPoolClosed {
+			t.Errorf("Waiting Get should have failed with ErrPoolClosed, got: %v\n", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting Get was not woken up by Close.")
+	}
+
+	pool.Put(client)
+	if client.isConnected() {
+		t.Error("Session returned to a closed pool should have been closed.")
+	}
+}
+
+func TestPool_PutDuringClose(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		pool, err := NewPool(context.Background(), 1, newPipePoolClient(&atomic.Int32{}))
+		if err != nil {
+			t.Fatalf("Creating a session pool failed: %v\n", err)
+		}
+		client, err := pool.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Getting a session failed: %v\n", err)
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pool.Put(client)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = pool.Close()
+		}()
+		wg.Wait()
+
+		if client.isConnected() || len(pool.sessions) > 0 {
+			t.Fatal("Session returned while closing the pool should have been closed.")
+		}
+	}
+}
+
+func newPipePoolClient(connections *atomic.Int32) func() (*Client, error) {
+	return func() (*Client, error) {
+		return New(
+			WithCredentials("test", "test123"),
+			WithTransport(&PipeTransport{Serve: servePipe(0, connections)}),
+		)
+	}
+}
+
+func newPoolTestClient() (*Client, error) {
+	return createEPPTestClient("test", "test123", "127.0.0.1", 12007)
+}
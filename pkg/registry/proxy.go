@@ -0,0 +1,335 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SOCKS5Dialer connects to the registry through a SOCKS5 proxy (RFC 1928),
+// authenticating with username and password (RFC 1929) if Username is set.
+// The registry host name is resolved by the proxy unless ResolveLocally is
+// set.
+type SOCKS5Dialer struct {
+	ProxyAddress string
+	Username     string
+	Password     string
+	// ResolveLocally resolves the host name before connecting, and sends
+	// the proxy its IP address, as with socks5:// URLs.
+	ResolveLocally bool
+	// Forward opens the connection to the proxy, *net.Dialer if nil.
+	Forward Dialer
+}
+
+// HTTPConnectDialer connects to the registry through an HTTP proxy using the
+// CONNECT method, with basic authentication if Username is set.
+type HTTPConnectDialer struct {
+	ProxyAddress string
+	Username     string
+	Password     string
+	// Forward opens the connection to the proxy, *net.Dialer if nil.
+	Forward Dialer
+}
+
+// NewProxyDialer creates a dialer from a proxy URL, either
+// socks5://[user:password@]host:port or http://[user:password@]host:port.
+// With socks5:// the registry host name is resolved locally as usual, and
+// with socks5h:// by the proxy.
+func NewProxyDialer(proxyURL string) (Dialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid proxy URL")
+	}
+	if u.Host == "" || u.Port() == "" {
+		return nil, errors.Errorf("Proxy URL must include host and port: %s", u.Redacted())
+	}
+
+	username := u.User.Username()
+	password, _ := u.User.Password()
+
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		return &SOCKS5Dialer{
+			ProxyAddress:   u.Host,
+			Username:       username,
+			Password:       password,
+			ResolveLocally: u.Scheme == "socks5",
+		}, nil
+	case "http":
+		return &HTTPConnectDialer{ProxyAddress: u.Host, Username: username, Password: password}, nil
+	}
+
+	return nil, errors.Errorf("Unsupported proxy scheme %s, use socks5, socks5h or http", u.Scheme)
+}
+
+const (
+	socksVersion         = 5
+	socksNoAuth          = 0
+	socksUserPassAuth    = 2
+	socksNoAcceptable    = 0xff
+	socksConnect         = 1
+	socksIPv4            = 1
+	socksDomain          = 3
+	socksIPv6            = 4
+	socksUserPassVersion = 1
+)
+
+var socksReplies = map[byte]string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+func (d *SOCKS5Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.ResolveLocally {
+		resolved, err := resolveAddress(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		address = resolved
+	}
+
+	conn, err := dialProxy(ctx, d.Forward, network, d.ProxyAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	err = withConnContext(ctx, conn, func() error {
+		return d.connect(conn, address)
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "SOCKS5 proxy %s failed to connect to %s", d.ProxyAddress, address)
+	}
+
+	return conn, nil
+}
+
+// resolveAddress replaces the host name in address with its first IP address.
+func resolveAddress(ctx context.Context, address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return address, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", errors.Wrapf(err, "Unable to resolve %s", host)
+	}
+
+	return net.JoinHostPort(addrs[0].IP.String(), port), nil
+}
+
+func (d *SOCKS5Dialer) connect(conn net.Conn, address string) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return errors.Errorf("invalid port %s", portStr)
+	}
+
+	method := byte(socksNoAuth)
+	if d.Username != "" {
+		method = socksUserPassAuth
+	}
+	if _, err = conn.Write([]byte{socksVersion, 1, method}); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion {
+		return errors.Errorf("unexpected SOCKS version %d", reply[0])
+	}
+	if reply[1] == socksNoAcceptable || reply[1] != method {
+		return errors.New("proxy did not accept the authentication method")
+	}
+
+	if method == socksUserPassAuth {
+		if len(d.Username) > 255 || len(d.Password) > 255 {
+			return errors.New("username and password must be at most 255 bytes")
+		}
+		auth := []byte{socksUserPassVersion, byte(len(d.Username))}
+		auth = append(auth, d.Username...)
+		auth = append(auth, byte(len(d.Password)))
+		auth = append(auth, d.Password...)
+		if _, err = conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err = io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0 {
+			return errors.New("proxy authentication failed")
+		}
+	}
+
+	request := []byte{socksVersion, socksConnect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(request, socksIPv4)
+			request = append(request, ip4...)
+		} else {
+			request = append(request, socksIPv6)
+			request = append(request, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return errors.New("host name is too long")
+		}
+		request = append(request, socksDomain, byte(len(host)))
+		request = append(request, host...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err = conn.Write(request); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0 {
+		reason, ok := socksReplies[header[1]]
+		if !ok {
+			reason = fmt.Sprintf("unknown error %d", header[1])
+		}
+		return errors.New(reason)
+	}
+
+	// Skip the bound address and port of the reply.
+	var addrLength int
+	switch header[3] {
+	case socksIPv4:
+		addrLength = net.IPv4len
+	case socksIPv6:
+		addrLength = net.IPv6len
+	case socksDomain:
+		length := make([]byte, 1)
+		if _, err = io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		addrLength = int(length[0])
+	default:
+		return errors.Errorf("unknown address type %d in reply", header[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, addrLength+2))
+
+	return err
+}
+
+func (d *HTTPConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := dialProxy(ctx, d.Forward, network, d.ProxyAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	var tunnel net.Conn
+	err = withConnContext(ctx, conn, func() error {
+		var err error
+		tunnel, err = d.connect(conn, address)
+		return err
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrapf(err, "HTTP proxy %s failed to connect to %s", d.ProxyAddress, address)
+	}
+
+	return tunnel, nil
+}
+
+func (d *HTTPConnectDialer) connect(conn net.Conn, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if d.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.Username + ":" + d.Password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("proxy responded %s", resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn returns data the proxy sent right after its response before
+// reading from the connection itself.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func dialProxy(ctx context.Context, forward Dialer, network, proxyAddress string) (net.Conn, error) {
+	if forward == nil {
+		forward = &net.Dialer{}
+	}
+
+	conn, err := forward.DialContext(ctx, network, proxyAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to connect to proxy %s", proxyAddress)
+	}
+
+	return conn, nil
+}
+
+// withConnContext runs the proxy handshake in fn, interrupting it if ctx is
+// done before it finishes.
+func withConnContext(ctx context.Context, conn net.Conn, fn func() error) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	stop := interruptOnDone(ctx, conn)
+
+	err := fn()
+	if !stop() && ctx.Err() != nil {
+		// Interrupted, the connection deadline is now in the past.
+		return ctx.Err()
+	}
+
+	return err
+}
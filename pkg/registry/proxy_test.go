@@ -0,0 +1,279 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_ProxyDialers(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12013)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	socksProxy, err := startTestProxy(serveSOCKS5(nil))
+	if err != nil {
+		t.Fatalf("Starting SOCKS5 proxy failed: %v\n", err)
+	}
+	defer socksProxy.Close()
+
+	httpProxy, err := startTestProxy(serveHTTPConnect)
+	if err != nil {
+		t.Fatalf("Starting HTTP proxy failed: %v\n", err)
+	}
+	defer httpProxy.Close()
+
+	dialers := map[string]string{
+		"SOCKS5":       "socks5://" + socksProxy.Addr().String(),
+		"SOCKS5 auth":  "socks5://proxyuser:proxypass@" + socksProxy.Addr().String(),
+		"HTTP CONNECT": "http://" + httpProxy.Addr().String(),
+		"HTTP auth":    "http://proxyuser:proxypass@" + httpProxy.Addr().String(),
+	}
+
+	for name, proxyURL := range dialers {
+		dialer, err := NewProxyDialer(proxyURL)
+		if err != nil {
+			t.Fatalf("%s: creating dialer failed: %v\n", name, err)
+		}
+
+		eppTestClient, err := createEPPTestClient("test", "test123", "127.0.0.1", 12013)
+		if err != nil {
+			t.Fatalf("Error when creating client for tests: %v\n", err)
+		}
+		eppTestClient.transport.(*TLSTransport).Dialer = dialer
+
+		if err = eppTestClient.Connect(); err != nil {
+			t.Errorf("%s: connecting through proxy failed: %v\n", name, err)
+			continue
+		}
+
+		eppTestServer.SetupNewResponses(helloReq, greeting, failedCommand)
+		if _, err = eppTestClient.Hello(); err != nil {
+			t.Errorf("%s: hello through proxy failed: %v\n", name, err)
+		}
+
+		if err = eppTestClient.Close(); err != nil {
+			t.Errorf("%s: closing the connection failed: %v\n", name, err)
+		}
+	}
+
+	failing := map[string]Dialer{
+		"SOCKS5 wrong password": &SOCKS5Dialer{ProxyAddress: socksProxy.Addr().String(), Username: "proxyuser", Password: "wrong"},
+		"HTTP wrong password":   &HTTPConnectDialer{ProxyAddress: httpProxy.Addr().String(), Username: "proxyuser", Password: "wrong"},
+		"SOCKS5 refused":        &SOCKS5Dialer{ProxyAddress: socksProxy.Addr().String()},
+	}
+	for name, dialer := range failing {
+		target := "127.0.0.1:12013"
+		if name == "SOCKS5 refused" {
+			target = "127.0.0.1:1"
+		}
+		if conn, err := dialer.DialContext(context.Background(), "tcp", target); err == nil {
+			conn.Close()
+			t.Errorf("%s: dialing should have failed.\n", name)
+		}
+	}
+
+	for _, proxyURL := range []string{"ftp://127.0.0.1:21", "socks5://127.0.0.1", "://"} {
+		if _, err = NewProxyDialer(proxyURL); err == nil {
+			t.Errorf("Creating dialer for %s should have failed.\n", proxyURL)
+		}
+	}
+}
+
+func TestSOCKS5Dialer_Resolving(t *testing.T) {
+	hosts := make(chan string, 1)
+	socksProxy, err := startTestProxy(serveSOCKS5(hosts))
+	if err != nil {
+		t.Fatalf("Starting SOCKS5 proxy failed: %v\n", err)
+	}
+	defer socksProxy.Close()
+
+	for scheme, local := range map[string]bool{"socks5": true, "socks5h": false} {
+		dialer, err := NewProxyDialer(scheme + "://" + socksProxy.Addr().String())
+		if err != nil {
+			t.Fatalf("%s: creating dialer failed: %v\n", scheme, err)
+		}
+
+		// Whether the proxy reaches the target does not matter, only the
+		// address it was asked to connect to.
+		if conn, err := dialer.DialContext(context.Background(), "tcp", "localhost:1"); err == nil {
+			conn.Close()
+		}
+		host := <-hosts
+		if resolved := net.ParseIP(host) != nil; resolved != local {
+			t.Errorf("%s: unexpected host sent to the proxy: %s\n", scheme, host)
+		}
+	}
+}
+
+func TestClient_ProxyDialerCancellation(t *testing.T) {
+	// Proxy accepting connections but never responding.
+	var accepted atomic.Int32
+	silentProxy, err := startTestProxy(func(conn net.Conn) {
+		accepted.Add(1)
+		_, _ = io.Copy(io.Discard, conn)
+	})
+	if err != nil {
+		t.Fatalf("Starting proxy failed: %v\n", err)
+	}
+	defer silentProxy.Close()
+
+	dialer := &SOCKS5Dialer{ProxyAddress: silentProxy.Addr().String()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err = dialer.DialContext(ctx, "tcp", "127.0.0.1:12013"); err == nil {
+		t.Fatal("Dialing through a silent proxy should have failed.")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Dialing was not interrupted by the context.")
+	}
+}
+
+func startTestProxy(handle func(conn net.Conn)) (net.Listener, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener, nil
+}
+
+// serveSOCKS5 relays connections, sending the requested hosts to hosts if it
+// is not nil.
+func serveSOCKS5(hosts chan<- string) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		handleSOCKS5(conn, hosts)
+	}
+}
+
+func handleSOCKS5(conn net.Conn, hosts chan<- string) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	if methods[0] == 2 {
+		_, _ = conn.Write([]byte{5, 2})
+
+		auth := make([]byte, 2)
+		_, _ = io.ReadFull(conn, auth)
+		username := make([]byte, auth[1])
+		_, _ = io.ReadFull(conn, username)
+		_, _ = io.ReadFull(conn, auth[:1])
+		password := make([]byte, auth[0])
+		_, _ = io.ReadFull(conn, password)
+
+		if string(username) != "proxyuser" || string(password) != "proxypass" {
+			_, _ = conn.Write([]byte{1, 1})
+			return
+		}
+		_, _ = conn.Write([]byte{1, 0})
+	} else {
+		_, _ = conn.Write([]byte{5, 0})
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		_, _ = io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		_, _ = io.ReadFull(conn, length)
+		name := make([]byte, length[0])
+		_, _ = io.ReadFull(conn, name)
+		host = string(name)
+	case 4:
+		ip := make([]byte, 16)
+		_, _ = io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	}
+	port := make([]byte, 2)
+	_, _ = io.ReadFull(conn, port)
+	if hosts != nil {
+		hosts <- host
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+	relay(conn, target)
+}
+
+func serveHTTPConnect(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil || req.Method != http.MethodConnect {
+		return
+	}
+
+	if auth := req.Header.Get("Proxy-Authorization"); auth != "" {
+		username, password, ok := (&http.Request{Header: http.Header{"Authorization": {auth}}}).BasicAuth()
+		if !ok || username != "proxyuser" || password != "proxypass" {
+			_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+			return
+		}
+	}
+
+	target, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		_, _ = conn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+		return
+	}
+	defer target.Close()
+
+	_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	relay(conn, target)
+}
+
+func relay(client, target net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(target, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, target)
+		done <- struct{}{}
+	}()
+	<-done
+}
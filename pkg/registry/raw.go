@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// ClTRIDPlaceholder is replaced with a new transaction ID in commands sent
+// with SendRaw, e.g. <clTRID>{{clTRID}}</clTRID>.
+const ClTRIDPlaceholder = "{{clTRID}}"
+
+func (s *Client) SendRaw(payload []byte) ([]byte, epp.Response, error) {
+	return s.SendRawContext(context.Background(), payload)
+}
+
+// SendRawContext sends an EPP document as is, e.g. for commands the client
+// does not implement or for debugging the registry's responses. The XML
+// declaration is optional, and ClTRIDPlaceholder is replaced with a new
+// transaction ID. The raw response is returned with its envelope parsed. If
+// the registry responds with an error code, both are returned along with an
+// *EPPError. Successful login and logout documents update the session state
+// as Login and Logout do.
+func (s *Client) SendRawContext(ctx context.Context, payload []byte) ([]byte, epp.Response, error) {
+	payload = bytes.ReplaceAll(stripXMLDeclaration(payload), []byte(ClTRIDPlaceholder), []byte(s.newTransactionID()))
+	if err := checkEPPDocument(payload); err != nil {
+		return nil, epp.Response{}, err
+	}
+
+	rawResp, err := s.SendContext(ctx, payload)
+	if err != nil {
+		return nil, epp.Response{}, err
+	}
+
+	var resp epp.Response
+	if err = xml.Unmarshal(rawResp, &resp); err != nil {
+		return rawResp, resp, errors.Wrap(err, "Unable to parse response")
+	}
+
+	switch command := summarizeMessage(payload).Command; {
+	case command == "login" && resp.Results.Code().IsSuccess():
+		s.setLoggedIn(true)
+		s.setSessionWanted(true, true)
+	case command == "logout" && resp.Results.Code() == epp.ResultEndingSession:
+		s.setLoggedIn(false)
+		s.setSessionWanted(true, false)
+	}
+
+	// The response to a hello is a greeting, without results.
+	if len(resp.Results) > 0 && !resp.Results.Code().IsSuccess() {
+		return rawResp, resp, newEPPError(resp.Results, resp.TrID)
+	}
+
+	return rawResp, resp, nil
+}
+
+// stripXMLDeclaration removes the declaration from a document, as the client
+// adds it to each frame it writes.
+func stripXMLDeclaration(payload []byte) []byte {
+	payload = bytes.TrimSpace(payload)
+	if !bytes.HasPrefix(payload, []byte("<?xml")) {
+		return payload
+	}
+
+	if end := bytes.Index(payload, []byte("?>")); end >= 0 {
+		return bytes.TrimSpace(payload[end+2:])
+	}
+	return payload
+}
+
+// checkEPPDocument checks that payload is well-formed XML with an epp root
+// element, so that malformed documents are not sent to the registry.
+func checkEPPDocument(payload []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(payload))
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "Invalid XML document")
+		}
+
+		if start, ok := token.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+
+	if root != "epp" {
+		return errors.New("Document is not an EPP message.")
+	}
+	return nil
+}
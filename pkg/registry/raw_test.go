@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"github.com/pkg/errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_SendRaw(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12013)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedRawPoll, newMessages, failedCommand)
+	raw, resp, err := eppTestClient.SendRaw([]byte(rawPoll))
+	if err != nil {
+		t.Fatalf("Sending raw command failed: %v\n", err)
+	}
+	if !strings.Contains(string(raw), "<msgQ") {
+		t.Errorf("Raw response should have been returned: %s\n", raw)
+	}
+	if resp.Results.Code() != epp.ResultAckToDequeue || resp.MsgQ == nil || resp.MsgQ.Count != 1 {
+		t.Errorf("Unexpected response envelope: %+v\n", resp)
+	}
+	if !strings.Contains(resp.ResData.XML, "C574767") {
+		t.Errorf("Response data should have been kept as XML: %s\n", resp.ResData.XML)
+	}
+	if resp.TrID.ClTRID == "" || resp.TrID.ClTRID == ClTRIDPlaceholder {
+		t.Errorf("Transaction ID should have been generated, got %q\n", resp.TrID.ClTRID)
+	}
+
+	eppTestServer.SetupNewResponses(expectedRawPoll, domainNotFound, failedCommand)
+	raw, resp, err = eppTestClient.SendRaw([]byte(rawPoll))
+	var eppErr *EPPError
+	if !errors.As(err, &eppErr) || eppErr.Code != epp.ResultObjectDoesNotExist {
+		t.Errorf("Error code should have been returned as EPPError, got: %v\n", err)
+	}
+	if raw == nil || resp.Results.Code() != epp.ResultObjectDoesNotExist {
+		t.Errorf("Failed response should have been returned with the error: %+v\n", resp)
+	}
+
+	if _, _, err = eppTestClient.SendRaw([]byte(`<epp><command>`)); err == nil {
+		t.Error("Malformed XML should have failed.")
+	}
+	if _, _, err = eppTestClient.SendRaw([]byte(`<hello/>`)); err == nil {
+		t.Error("Document without an epp root should have failed.")
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the client connection failed: %s", err)
+	}
+}
+
+func TestClient_SendRawSession(t *testing.T) {
+	var connections atomic.Int32
+	client, err := New(
+		WithCredentials("test", "test123"),
+		WithTransport(&PipeTransport{Serve: servePipe(0, &connections)}),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	defer client.Close()
+
+	if _, _, err = client.SendRaw([]byte(rawLogin)); err != nil {
+		t.Fatalf("Sending raw login failed: %v\n", err)
+	}
+	if !client.IsLoggedIn() {
+		t.Error("Raw login should have logged the client in.")
+	}
+
+	if _, _, err = client.SendRaw([]byte(rawLogout)); err != nil {
+		t.Fatalf("Sending raw logout failed: %v\n", err)
+	}
+	if client.IsLoggedIn() {
+		t.Error("Raw logout should have logged the client out.")
+	}
+}
+
+var rawLogin = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <login>
+      <clID>test</clID>
+      <pw>test123</pw>
+      <options>
+        <version>1.0</version>
+        <lang>en</lang>
+      </options>
+      <svcs>
+        <objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+      </svcs>
+    </login>
+    <clTRID>{{clTRID}}</clTRID>
+  </command>
+</epp>`
+
+var rawLogout = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <logout/>
+    <clTRID>{{clTRID}}</clTRID>
+  </command>
+</epp>`
+
+var rawPoll = `<?xml version="1.0" encoding="UTF-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <poll op="req"></poll>
+    <clTRID>` + ClTRIDPlaceholder + `</clTRID>
+  </command>
+</epp>
+`
+
+var expectedRawPoll = `<?xml version="1.0" encoding="UTF-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <poll op="req"></poll>
+    <clTRID>REPLACE_REQ_ID</clTRID>
+  </command>
+</epp>`
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Elements holding passwords, transfer and registry lock keys or personal
+// data, which are masked in logged messages regardless of their prefix.
+var redactedElements = []string{
+	"pw",                   // login, domain:pw and contact:pw
+	"newPW",                // password change
+	"pwregistranttransfer", // domain ownership change key
+	"authkey",              // registry lock
+	"identity",             // Finnish personal identity number
+	"birthDate",
+}
+
+var redactPattern = regexp.MustCompile(`(<(?:[\w.-]+:)?(?:` + strings.Join(redactedElements, "|") + `)(?:\s[^>]*)?>)(?:[^<]+|<!\[CDATA\[[\s\S]*?\]\]>)*(</)`)
+
+// redactMessage masks the contents of secret elements in a raw EPP message.
+func redactMessage(message []byte) string {
+	return redactPattern.ReplaceAllString(string(message), "${1}"+redacted+"${2}")
+}
+
+// logMessage logs a message sent to or received from the registry on debug
+// level. Secrets are masked unless unsafe logging has been enabled.
+func (s *Client) logMessage(ctx context.Context, msg string, message []byte) {
+	if !s.log.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	logged := string(message)
+	if !s.unsafeLogging {
+		logged = redactMessage(message)
+	}
+
+	s.log.DebugContext(ctx, msg, "message", logged)
+}
+
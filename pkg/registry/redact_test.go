@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactMessage(t *testing.T) {
+	messages := map[string][]string{
+		expectedLogin:                   {"test123"},
+		expectedPasswordChange:          {"test123", "N3w-Passw0rd"},
+		contactInfoResponse:             {"123423A123F", "2005-04-03"},
+		domainInfoResponse:              {"2fooBAR"},
+		expectedDomainTransferKeyUpdate: {"fgs+562Fds"},
+		expectedDomainTransfer:          {"fooBar45+Test"},
+		secretDomainUpdate:              {"brokerKey1", "ownerKey2", "lockKey3", "cdataKey4"},
+	}
+
+	for message, secrets := range messages {
+		redactedMessage := redactMessage([]byte(message))
+		for _, secret := range secrets {
+			if strings.Contains(redactedMessage, secret) {
+				t.Errorf("Secret %s was not redacted:\n%s\n", secret, redactedMessage)
+			}
+		}
+		if !strings.Contains(redactedMessage, redacted) {
+			t.Errorf("Redacted message is missing the mask:\n%s\n", redactedMessage)
+		}
+	}
+
+	if redactedMessage := redactMessage([]byte(domainInfoResponse)); !strings.Contains(redactedMessage, "<domain:pw>"+redacted+"</domain:pw>") ||
+		!strings.Contains(redactedMessage, "<domain:name>testdomain2.fi</domain:name>") {
+		t.Errorf("Only the contents of secret elements should be masked:\n%s\n", redactedMessage)
+	}
+}
+
+func TestClient_RedactedDebugLogs(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12012)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	var logs bytes.Buffer
+	eppTestClient.log = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedPasswordChange, successfulLogin, failedLogin)
+	if err = eppTestClient.ChangePassword("N3w-Passw0rd"); err != nil {
+		t.Fatalf("Password change failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+	if _, err = eppTestClient.GetDomain("testdomain2.fi"); err != nil {
+		t.Fatalf("Fetching domain failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedContactInfo, contactInfoResponse, failedCommand)
+	if _, err = eppTestClient.GetContact("username2"); err != nil {
+		t.Fatalf("Fetching contact failed: %v\n", err)
+	}
+
+	for _, secret := range []string{"test123", "N3w-Passw0rd", "2fooBAR", "123423A123F"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Secret %s was found in debug logs.\n", secret)
+		}
+	}
+	if !strings.Contains(logs.String(), "testdomain2.fi") {
+		t.Error("Messages should have been logged on debug level.")
+	}
+
+	logs.Reset()
+	eppTestClient.unsafeLogging = true
+
+	eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+	if _, err = eppTestClient.GetDomain("testdomain2.fi"); err != nil {
+		t.Fatalf("Fetching domain failed: %v\n", err)
+	}
+	if !strings.Contains(logs.String(), "2fooBAR") {
+		t.Error("Secrets should be logged when unsafe logging is enabled.")
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
+var secretDomainUpdate = `<?xml version="1.0" encoding="UTF-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <update>
+      <domain:update xmlns:domain="urn:ietf:params:xml:ns:domain-1.0">
+        <domain:name>testdomain2.fi</domain:name>
+        <domain:chg>
+          <domain:authInfo>
+            <domain:pw>brokerKey1</domain:pw>
+            <domain:pwregistranttransfer>ownerKey2</domain:pwregistranttransfer>
+          </domain:authInfo>
+          <domain:registrylock type="deactivate">
+            <domain:smsnumber>+358401234567</domain:smsnumber>
+            <domain:authkey>lockKey3</domain:authkey>
+          </domain:registrylock>
+        </domain:chg>
+      </domain:update>
+    </update>
+    <extension>
+      <pw><![CDATA[cdataKey4]]></pw>
+    </extension>
+    <clTRID>ABC12</clTRID>
+  </command>
+</epp>`
@@ -0,0 +1,181 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// reloadDelay groups the events of writing the certificate and the key, so
+// that the pair is loaded once both have been replaced.
+const reloadDelay = 200 * time.Millisecond
+
+// CertificateReloader watches PEM encoded client certificate and key files,
+// loading them again when they change. A renewed certificate is taken into
+// use on the next connection when the reloader is given to the client with
+// WithCertificateReloader. The current certificate is kept if the new files
+// cannot be loaded or the key does not match the certificate.
+type CertificateReloader struct {
+	certPath   string
+	keyPath    string
+	passphrase string
+	log        *slog.Logger
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	leaf *x509.Certificate
+
+	watcher   *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCertificateReloader loads the certificate and key, and starts watching
+// the directories they are in, which also covers files replaced by renaming.
+// The key is decrypted with passphrase if it is encrypted. Close stops
+// watching.
+func NewCertificateReloader(certPath, keyPath, passphrase string, log *slog.Logger) (*CertificateReloader, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	r := &CertificateReloader{
+		certPath:   filepath.Clean(certPath),
+		keyPath:    filepath.Clean(keyPath),
+		passphrase: passphrase,
+		log:        log,
+		done:       make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to watch certificate files")
+	}
+	for _, dir := range []string{filepath.Dir(r.certPath), filepath.Dir(r.keyPath)} {
+		if err = watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, errors.Wrapf(err, "Unable to watch %s", dir)
+		}
+	}
+	r.watcher = watcher
+
+	go r.watch()
+
+	return r, nil
+}
+
+// Reload loads the certificate and key files, replacing the current
+// certificate if they are a valid pair.
+func (r *CertificateReloader) Reload() error {
+	certPEM, err := ioutil.ReadFile(r.certPath)
+	if err != nil {
+		return errors.Wrap(err, "Unable to load client certificate from "+r.certPath)
+	}
+	keyPEM, err := ioutil.ReadFile(r.keyPath)
+	if err != nil {
+		return errors.Wrap(err, "Unable to load client key from "+r.keyPath)
+	}
+
+	// LoadKeyPair checks that the key matches the certificate.
+	cert, err := LoadKeyPair(certPEM, keyPEM, r.passphrase)
+	if err != nil {
+		return err
+	}
+	leaf, err := leafCertificate(cert)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leaf != nil && bytes.Equal(r.leaf.Raw, leaf.Raw) {
+		return nil
+	}
+	if r.leaf != nil {
+		r.log.Info("Client certificate reloaded.", "subject", leaf.Subject.String(), "notAfter", leaf.NotAfter)
+	}
+	r.cert = &cert
+	r.leaf = leaf
+
+	return nil
+}
+
+// Certificate returns the client certificate currently in use.
+func (r *CertificateReloader) Certificate() *x509.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.leaf
+}
+
+// GetClientCertificate is the tls.Config callback returning the current
+// certificate.
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Close stops watching the files. The last loaded certificate remains in use.
+func (r *CertificateReloader) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.done)
+		err = r.watcher.Close()
+	})
+
+	return err
+}
+
+func (r *CertificateReloader) watch() {
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			timer.Stop()
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if r.isWatched(event.Name) {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			r.log.Warn("Watching client certificate failed.", "error", err)
+		case <-timer.C:
+			if err := r.Reload(); err != nil {
+				r.log.Warn("Reloading client certificate failed, keeping the current one.", "error", err)
+			}
+		}
+	}
+}
+
+// isWatched reports whether an event concerns the certificate or key. Other
+// files in the directories are watched as well, since e.g. Kubernetes
+// replaces mounted secrets by swapping a symbolic link in the directory.
+func (r *CertificateReloader) isWatched(name string) bool {
+	name = filepath.Clean(name)
+	if name == r.certPath || name == r.keyPath {
+		return true
+	}
+
+	return filepath.Base(name) == "..data"
+}
@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_CertificateReload(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12017)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+	clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+	caCert, _ := ioutil.ReadFile("../../testtmp/rootCA.crt")
+	expiredCert, expiredKey, err := createExpiredCertificate()
+	if err != nil {
+		t.Fatalf("Creating expired certificate failed: %v\n", err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	writePair := func(certPEM, keyPEM []byte) {
+		if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+			t.Fatalf("Writing key failed: %v\n", err)
+		}
+		if err := ioutil.WriteFile(certPath, certPEM, 0600); err != nil {
+			t.Fatalf("Writing certificate failed: %v\n", err)
+		}
+	}
+	writePair(clientCert, clientKey)
+
+	reloader, err := NewCertificateReloader(certPath, keyPath, "", nil)
+	if err != nil {
+		t.Fatalf("Creating reloader failed: %v\n", err)
+	}
+	defer reloader.Close()
+	original := reloader.Certificate()
+
+	client, err := New(
+		WithServer("127.0.0.1", 12017),
+		WithCredentials("test", "test123"),
+		WithCACertificates(caCert),
+		WithCertificateReloader(reloader),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting with the reloaded certificate failed: %v\n", err)
+	}
+	_ = client.Close()
+
+	// A key not matching the certificate is not taken into use.
+	writePair(clientCert, expiredKey)
+	if err = reloader.Reload(); err == nil {
+		t.Error("Reloading a mismatching pair should have failed.")
+	}
+	if reloader.Certificate() != original {
+		t.Error("Certificate should not have been replaced by a mismatching pair.")
+	}
+
+	writePair(expiredCert, expiredKey)
+	if !waitForCertificate(func() bool { return reloader.Certificate().Subject.CommonName == "expired" }) {
+		t.Fatal("Changed certificate was not reloaded.")
+	}
+	if !client.CertificateExpiresAt().Before(time.Now()) {
+		t.Error("Client should report the expiry of the reloaded certificate.")
+	}
+	if err = client.Connect(); err == nil {
+		_ = client.Close()
+		t.Error("Reconnecting should have used the reloaded, expired certificate.")
+	}
+
+	writePair(clientCert, clientKey)
+	if !waitForCertificate(func() bool { return reloader.Certificate().Equal(original) }) {
+		t.Fatal("Renewed certificate was not reloaded.")
+	}
+	if err = client.Connect(); err != nil {
+		t.Errorf("Reconnecting with the renewed certificate failed: %v\n", err)
+	}
+	_ = client.Close()
+}
+
+func TestCertificateReloader_ConcurrentClose(t *testing.T) {
+	clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+	clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	_ = ioutil.WriteFile(certPath, clientCert, 0600)
+	_ = ioutil.WriteFile(keyPath, clientKey, 0600)
+
+	for i := 0; i < 20; i++ {
+		reloader, err := NewCertificateReloader(certPath, keyPath, "", nil)
+		if err != nil {
+			t.Fatalf("Creating reloader failed: %v\n", err)
+		}
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = reloader.Close()
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func waitForCertificate(reloaded func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if reloaded() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
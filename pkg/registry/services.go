@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"github.com/pkg/errors"
+)
+
+// Object and extension services implemented by the library, in the order
+// they are requested when logging in. All object services are requested, as
+// the FI registry serves hosts without announcing host-1.0 in its greeting,
+// and only extensions announced by the server are requested.
+var (
+	supportedObjects    = []string{epp.DomainNamespace, epp.HostNamespace, epp.ContactNamespace}
+	supportedExtensions = []string{epp.SecDNSNamespace, epp.DomainExtNamespace}
+)
+
+var ErrServiceNotAnnounced = errors.New("Service has not been announced by the server.")
+
+// Supports reports whether namespace is an object or extension service that
+// both the server announced in its greeting and the library implements.
+// Commands using extensions fail with ErrServiceNotAnnounced unless they are
+// supported. Commands on objects are sent regardless, as object services the
+// library implements are always requested when logging in.
+func (s *Client) Supports(namespace string) bool {
+	objURIs, extURIs := s.negotiatedServices()
+
+	return contains(objURIs, namespace) || contains(extURIs, namespace)
+}
+
+// negotiatedServices returns the object and extension services announced in
+// the greeting that are also supported by the library.
+func (s *Client) negotiatedServices() ([]string, []string) {
+	svcMenu := s.ServerGreeting().SvcMenu
+
+	return intersect(supportedObjects, svcMenu.ObjURI), intersect(supportedExtensions, svcMenu.SvcExtension.ExtURI)
+}
+
+// loginServices returns the services requested when logging in: all object
+// services of the library and the negotiated extensions.
+func (s *Client) loginServices() ([]string, []string) {
+	_, extURIs := s.negotiatedServices()
+
+	return append([]string(nil), supportedObjects...), extURIs
+}
+
+// requireService fails with ErrServiceNotAnnounced if a command would use a
+// service the server has not announced.
+func (s *Client) requireService(namespace string) error {
+	if !s.Supports(namespace) {
+		return errors.Wrapf(ErrServiceNotAnnounced, "Unable to use %s", namespace)
+	}
+
+	return nil
+}
+
+func (s *Client) checkServices(greeting epp.Greeting) error {
+	for _, extURI := range greeting.SvcMenu.SvcExtension.ExtURI {
+		if !contains(supportedExtensions, extURI) {
+			s.log.Warn("Server announced an unknown extension, it will not be used.", "extURI", extURI)
+		}
+	}
+
+	if len(intersect(supportedObjects, greeting.SvcMenu.ObjURI)) == 0 {
+		return errors.New("Server does not announce any supported object services.")
+	}
+
+	return nil
+}
+
+func intersect(supported, announced []string) []string {
+	var common []string
+	for _, namespace := range supported {
+		if contains(announced, namespace) {
+			common = append(common, namespace)
+		}
+	}
+
+	return common
+}
+
+func contains(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"strings"
+	"testing"
+)
+
+func TestClient_Supports(t *testing.T) {
+	eppTestClient, err := createEPPTestClient("test", "test123", "127.0.0.1", 12009)
+	if err != nil {
+		t.Fatalf("Error when creating client for tests: %v\n", err)
+	}
+
+	if eppTestClient.Supports(epp.DomainNamespace) {
+		t.Error("Nothing should be supported before receiving a greeting.")
+	}
+
+	greeting, err := unmarshalGreeting([]byte(greetingWithAllServices))
+	if err != nil {
+		t.Fatalf("Unmarshalling greeting failed: %v\n", err)
+	}
+	eppTestClient.setGreeting(greeting)
+
+	for _, namespace := range []string{epp.DomainNamespace, epp.HostNamespace, epp.ContactNamespace, epp.SecDNSNamespace, epp.DomainExtNamespace} {
+		if !eppTestClient.Supports(namespace) {
+			t.Errorf("Namespace %s should be supported.\n", namespace)
+		}
+	}
+	if eppTestClient.Supports("urn:ietf:params:xml:ns:nsset-1.2") {
+		t.Error("Announced but unimplemented object service should not be supported.")
+	}
+}
+
+func TestClient_NegotiatedServices(t *testing.T) {
+	eppTestClient, err := createEPPTestClient("test", "test123", "127.0.0.1", 12009)
+	if err != nil {
+		t.Fatalf("Error when creating client for tests: %v\n", err)
+	}
+
+	greeting, err := unmarshalGreeting([]byte(greetingWithoutSecDNS))
+	if err != nil {
+		t.Fatalf("Unmarshalling greeting failed: %v\n", err)
+	}
+	if err = eppTestClient.checkServices(greeting); err != nil {
+		t.Fatalf("Greeting with supported object services was rejected: %v\n", err)
+	}
+	eppTestClient.setGreeting(greeting)
+
+	if eppTestClient.Supports(epp.SecDNSNamespace) {
+		t.Error("Unannounced extension should not be supported.")
+	}
+
+	loginData, err := eppTestClient.loginMessage("")
+	if err != nil {
+		t.Fatalf("Creating login message failed: %v\n", err)
+	}
+	var login epp.APILogin
+	if err = xml.Unmarshal(loginData, &login); err != nil {
+		t.Fatalf("Unmarshalling login message failed: %v\n", err)
+	}
+	objURIs := strings.Join(login.Command.Login.Svcs.ObjURI, " ")
+	extURIs := strings.Join(login.Command.Login.Svcs.SvcExtension.ExtURI, " ")
+	// The FI registry does not announce host-1.0, but serves hosts.
+	if eppTestClient.Supports(epp.HostNamespace) {
+		t.Error("Unannounced object service should not be supported.")
+	}
+	if objURIs != epp.DomainNamespace+" "+epp.HostNamespace+" "+epp.ContactNamespace {
+		t.Errorf("Login should request all object services of the library, got %s\n", objURIs)
+	}
+	if extURIs != epp.DomainExtNamespace {
+		t.Errorf("Login should only request negotiated extensions, got %s\n", extURIs)
+	}
+
+	extension := epp.NewDomainDNSSecUpdateExtension(nil, nil, true)
+	err = eppTestClient.UpdateDomainExtensions("testdomain.fi", extension)
+	if !errors.Is(err, ErrServiceNotAnnounced) {
+		t.Errorf("Using an unannounced extension should have failed, got: %v\n", err)
+	}
+
+	greeting.SvcMenu.ObjURI = []string{"urn:ietf:params:xml:ns:nsset-1.2"}
+	if err = eppTestClient.checkServices(greeting); err == nil {
+		t.Error("Greeting without supported object services should have been rejected.")
+	}
+}
+
+var greetingWithAllServices = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+<greeting>
+  <svID>Ficora EPP Server</svID>
+  <svDate>2020-06-20T23:59:59.9720308+02:00</svDate>
+  <svcMenu>
+    <version>1.0</version>
+    <lang>en</lang>
+    <objURI>urn:ietf:params:xml:ns:contact-1.0</objURI>
+    <objURI>urn:ietf:params:xml:ns:nsset-1.2</objURI>
+    <objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+    <objURI>urn:ietf:params:xml:ns:host-1.0</objURI>
+    <svcExtension>
+      <extURI>urn:ietf:params:xml:ns:secDNS-1.1</extURI>
+      <extURI>urn:ietf:params:xml:ns:domain-ext-1.0</extURI>
+    </svcExtension>
+  </svcMenu>
+</greeting>
+</epp>`
+
+var greetingWithoutSecDNS = `<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+<greeting>
+  <svID>Ficora EPP Server</svID>
+  <svDate>2020-06-20T23:59:59.9720308+02:00</svDate>
+  <svcMenu>
+    <version>1.0</version>
+    <lang>en</lang>
+    <objURI>urn:ietf:params:xml:ns:contact-1.0</objURI>
+    <objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+    <svcExtension>
+      <extURI>urn:ietf:params:xml:ns:domain-ext-1.0</extURI>
+      <extURI>urn:ietf:params:xml:ns:unknown-1.0</extURI>
+    </svcExtension>
+  </svcMenu>
+</greeting>
+</epp>`
@@ -1,62 +1,86 @@
 package registry
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/ajmyyra/go-epp-fi/pkg/epp"
 	"github.com/pkg/errors"
 )
 
 func (s *Client) Login() error {
+	return s.LoginContext(context.Background())
+}
+
+func (s *Client) LoginContext(ctx context.Context) error {
+	loginData, err := s.loginMessage("")
+	if err != nil {
+		return err
+	}
+
+	rawResult, err := s.SendContext(ctx, loginData)
+	if err != nil {
+		return errors.Wrap(err, "Login failed")
+	}
+
+	return s.handleLoginResult(rawResult)
+}
+
+func (s *Client) loginMessage(newPassword string) ([]byte, error) {
+	credentials := s.loginCredentials()
+
 	loginDetails := epp.Login{}
-	loginDetails.ClID = s.credentials.username
-	loginDetails.Pw = s.credentials.password
+	loginDetails.ClID = credentials.username
+	loginDetails.Pw = credentials.password
+	loginDetails.NewPW = newPassword
 
 	loginDetails.Options.Version = APIVersion
 	loginDetails.Options.Lang = APILanguage
 
-	loginDetails.Svcs.ObjURI = []string{epp.DomainNamespace, epp.HostNamespace, epp.ContactNamespace}
-	loginDetails.Svcs.SvcExtension.ExtURI = []string{epp.SecDNSNamespace, epp.DomainExtNamespace}
+	loginDetails.Svcs.ObjURI, loginDetails.Svcs.SvcExtension.ExtURI = s.loginServices()
 
 	EPPLogin := epp.APILogin{}
 	EPPLogin.Xmlns = epp.EPPNamespace
 	EPPLogin.Command.Login = loginDetails
-	EPPLogin.Command.ClTRID = createRequestID(reqIDLength)
+	EPPLogin.Command.ClTRID = s.newTransactionID()
 
 	loginData, err := xml.MarshalIndent(EPPLogin, "", "  ")
 	if err != nil {
-		return errors.Wrap(err, "Problem converting login message to XML")
+		return nil, errors.Wrap(err, "Problem converting login message to XML")
 	}
 
-	rawResult, err := s.Send(loginData)
-	if err != nil {
-		return errors.Wrap(err, "Login failed")
-	}
+	return loginData, nil
+}
 
+func (s *Client) handleLoginResult(rawResult []byte) error {
 	loginResult := epp.APIResult{}
-	if err = xml.Unmarshal(rawResult, &loginResult); err != nil {
+	if err := xml.Unmarshal(rawResult, &loginResult); err != nil {
 		return errors.Wrap(err, "Unrecognised result body")
 	}
 
-	result := loginResult.Response.Result
-	if result.Code != 1000 {
-		return errors.New(result.Msg)
+	if !loginResult.Response.Results.Code().IsSuccess() {
+		return newEPPError(loginResult.Response.Results, loginResult.Response.TrID)
 	}
 
-	s.LoggedIn = true
+	s.setLoggedIn(true)
+	s.setSessionWanted(true, true)
 	return nil
 }
 
 func (s *Client) Logout() error {
+	return s.LogoutContext(context.Background())
+}
+
+func (s *Client) LogoutContext(ctx context.Context) error {
 	EPPLogout := epp.APILogout{}
 	EPPLogout.Xmlns = epp.EPPNamespace
-	EPPLogout.Command.ClTRID = createRequestID(reqIDLength)
+	EPPLogout.Command.ClTRID = s.newTransactionID()
 
 	logoutData, err := xml.MarshalIndent(EPPLogout, "", "  ")
 	if err != nil {
 		return errors.Wrap(err, "Problem converting logout message to XML")
 	}
 
-	rawResult, err := s.Send(logoutData)
+	rawResult, err := s.SendContext(ctx, logoutData)
 	if err != nil {
 		return errors.Wrap(err, "Logout failed")
 	}
@@ -66,16 +90,50 @@ func (s *Client) Logout() error {
 		return errors.Wrap(err,"Unrecognised result body")
 	}
 
-	result := logoutResult.Response.Result
-	if result.Code != 1500 {
-		return errors.New(result.Msg)
+	if logoutResult.Response.Results.Code() != epp.ResultEndingSession {
+		return newEPPError(logoutResult.Response.Results, logoutResult.Response.TrID)
 	}
 
-	s.LoggedIn = false
+	s.setLoggedIn(false)
+	s.setSessionWanted(true, false)
 	return nil
 }
 
-func (s *Client) ChangePassword() error {
-	// TODO
+// ChangePassword logs in and changes the password of the account to
+// newPassword, which is validated with ValidatePassword first. The client
+// uses the new password from then on, also when logging back in after a
+// reconnect. The session must not be logged in before changing the password.
+func (s *Client) ChangePassword(newPassword string) error {
+	return s.ChangePasswordContext(context.Background(), newPassword)
+}
+
+func (s *Client) ChangePasswordContext(ctx context.Context, newPassword string) error {
+	if s.IsLoggedIn() {
+		return errors.New("Password can only be changed when logging in, log out first.")
+	}
+
+	if newPassword == s.loginCredentials().password {
+		return errors.New("New password must differ from the current one.")
+	}
+
+	if err := ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	loginData, err := s.loginMessage(newPassword)
+	if err != nil {
+		return err
+	}
+
+	rawResult, err := s.SendContext(ctx, loginData)
+	if err != nil {
+		return errors.Wrap(err, "Password change failed")
+	}
+
+	if err = s.handleLoginResult(rawResult); err != nil {
+		return err
+	}
+
+	s.setPassword(newPassword)
 	return nil
 }
\ No newline at end of file
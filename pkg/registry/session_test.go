@@ -57,6 +57,40 @@ func TestFailedLogin(t *testing.T) {
 	}
 }
 
+func TestChangePassword(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12002)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	if err = eppTestClient.ChangePassword("weakpass"); err == nil {
+		t.Error("Password change with a weak password should have failed.")
+	}
+
+	eppTestServer.SetupNewResponses(expectedPasswordChange, successfulLogin, failedLogin)
+
+	if err = eppTestClient.ChangePassword("N3w-Passw0rd"); err != nil {
+		t.Fatalf("Password change failed: %v\n", err)
+	}
+
+	if eppTestClient.loginCredentials().password != "N3w-Passw0rd" {
+		t.Error("Stored password was not updated.")
+	}
+
+	if err = eppTestClient.ChangePassword("0ther-Passw0rd"); err == nil {
+		t.Error("Password change should have failed when logged in.")
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
+
 var expectedLogin = `<?xml version="1.0" encoding="UTF-8"?>
 <epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
   <command>
@@ -81,6 +115,31 @@ var expectedLogin = `<?xml version="1.0" encoding="UTF-8"?>
   </command>
 </epp>`
 
+var expectedPasswordChange = `<?xml version="1.0" encoding="UTF-8"?>
+<epp xmlns="urn:ietf:params:xml:ns:epp-1.0">
+  <command>
+    <login>
+      <clID>test</clID>
+      <pw>test123</pw>
+      <newPW>N3w-Passw0rd</newPW>
+      <options>
+        <version>1.0</version>
+        <lang>en</lang>
+      </options>
+      <svcs>
+        <objURI>urn:ietf:params:xml:ns:domain-1.0</objURI>
+        <objURI>urn:ietf:params:xml:ns:host-1.0</objURI>
+        <objURI>urn:ietf:params:xml:ns:contact-1.0</objURI>
+        <svcExtension>
+          <extURI>urn:ietf:params:xml:ns:secDNS-1.1</extURI>
+          <extURI>urn:ietf:params:xml:ns:domain-ext-1.0</extURI>
+        </svcExtension>
+      </svcs>
+    </login>
+    <clTRID>REPLACE_REQ_ID</clTRID>
+  </command>
+</epp>`
+
 var successfulLogin = `<?xml version="1.0" encoding="utf-8"?>
 <epp xmlns:host="urn:ietf:params:xml:ns:host-1.0" xmlns:domain="urn:ietf:params:xml:ns:domain-1.0" xmlns:contact="urn:ietf:params:xml:ns:contact-1.0" xmlns:obj="urn:ietf:params:xml:ns:obj-1.0" xmlns="urn:ietf:params:xml:ns:epp-1.0">
   <response>
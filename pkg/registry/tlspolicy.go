@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// CertificatePinError is returned when connecting if none of the certificates
+// presented by the server matches the pinned public keys.
+type CertificatePinError struct {
+	ServerName string
+	// Presented holds the SPKI fingerprints of the server's certificates.
+	Presented []string
+	Expected  []string
+}
+
+func (e *CertificatePinError) Error() string {
+	return fmt.Sprintf("Certificate pin mismatch for %s: server presented %s, expected one of %s",
+		e.ServerName, strings.Join(e.Presented, ", "), strings.Join(e.Expected, ", "))
+}
+
+// SPKIFingerprint returns the base64 encoded SHA-256 digest of the subject
+// public key info of cert, the format used by WithPinnedKeys. The pin of a
+// server can also be calculated with openssl:
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der |
+//	  openssl dgst -sha256 -binary | base64
+func SPKIFingerprint(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
+
+func parsePin(pin string) (string, error) {
+	pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+
+	digest, err := base64.StdEncoding.DecodeString(pin)
+	if err != nil || len(digest) != sha256.Size {
+		return "", errors.Errorf("Invalid certificate pin %s, expected base64 encoded SHA-256 digest", pin)
+	}
+
+	return pin, nil
+}
+
+// verifyPins returns a VerifyConnection callback checking that a certificate
+// in the verified chain matches one of the pins. Pinning the key of an
+// intermediate or root CA restricts the registry to certificates from it.
+func verifyPins(pins []string, serverName string, next func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if next != nil {
+			if err := next(cs); err != nil {
+				return err
+			}
+		}
+
+		// Only certificates of verified chains count, as the server could
+		// include any certificate in the ones it presents. Without
+		// verification, only the server's own certificate is checked.
+		var certs []*x509.Certificate
+		for _, chain := range cs.VerifiedChains {
+			certs = append(certs, chain...)
+		}
+		if len(cs.VerifiedChains) == 0 && len(cs.PeerCertificates) > 0 {
+			certs = cs.PeerCertificates[:1]
+		}
+
+		var presented []string
+		for _, cert := range certs {
+			fingerprint := SPKIFingerprint(cert)
+			for _, pin := range pins {
+				if fingerprint == pin {
+					return nil
+				}
+			}
+			if !contains(presented, fingerprint) {
+				presented = append(presented, fingerprint)
+			}
+		}
+
+		return &CertificatePinError{ServerName: serverName, Presented: presented, Expected: pins}
+	}
+}
+
+// ParseTLSVersion converts a version like "1.2" or "1.3" to its tls constant.
+func ParseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToUpper(version), "TLS") {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, errors.Errorf("Unknown TLS version: %s", version)
+}
+
+// ParseCipherSuites converts cipher suite names, e.g.
+// TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, to their IDs. Suites considered
+// insecure by crypto/tls are rejected.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range names {
+		id, err := cipherSuiteID(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func cipherSuiteID(name string) (uint16, error) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, nil
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return 0, errors.Errorf("Cipher suite %s is insecure", name)
+		}
+	}
+
+	return 0, errors.Errorf("Unknown cipher suite: %s", name)
+}
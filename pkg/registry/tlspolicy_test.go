@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestClient_TLSPolicy(t *testing.T) {
+	eppTestServer, err := createEPPTestServer("127.0.0.1", 12014)
+	if err != nil {
+		t.Fatalf("Error when creating server for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	serverCert, _, err := loadTestServerCerts()
+	if err != nil {
+		t.Fatalf("Loading server certificate failed: %v\n", err)
+	}
+	leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Parsing server certificate failed: %v\n", err)
+	}
+	serverPin := SPKIFingerprint(leaf)
+
+	caPEM, _ := ioutil.ReadFile("../../testtmp/rootCA.crt")
+	caPool := x509.NewCertPool()
+	caPool.AppendCertsFromPEM(caPEM)
+	wrongPin := "sha256/" + strings.Repeat("A", 43) + "="
+
+	connect := func(opts ...Option) (*Client, error) {
+		clientCert, _ := ioutil.ReadFile("../../testtmp/testclient.crt")
+		clientKey, _ := ioutil.ReadFile("../../testtmp/testclient.key")
+		opts = append([]Option{
+			WithServer("127.0.0.1", 12014),
+			WithCredentials("test", "test123"),
+			WithClientCertificate(clientCert, clientKey),
+			WithCAPool(caPool),
+		}, opts...)
+
+		client, err := New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return client, client.Connect()
+	}
+
+	client, err := connect(WithMinTLSVersion(tls.VersionTLS13), WithPinnedKeys(wrongPin, serverPin))
+	if err != nil {
+		t.Fatalf("Connecting with a matching pin failed: %v\n", err)
+	}
+	if version := client.transport.(*TLSTransport).ConnectionState().Version; version != tls.VersionTLS13 {
+		t.Errorf("Expected TLS 1.3, got %#x\n", version)
+	}
+	_ = client.Close()
+
+	client, err = connect(
+		WithMinTLSVersion(tls.VersionTLS12),
+		WithTLSConfig(&tls.Config{MaxVersion: tls.VersionTLS12}),
+		WithCipherSuites(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256),
+	)
+	if err != nil {
+		t.Fatalf("Connecting with TLS 1.2 and a given cipher suite failed: %v\n", err)
+	}
+	if suite := client.transport.(*TLSTransport).ConnectionState().CipherSuite; suite != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("Unexpected cipher suite: %s\n", tls.CipherSuiteName(suite))
+	}
+	_ = client.Close()
+
+	client, err = connect(WithPinnedKeys(wrongPin))
+	var pinErr *CertificatePinError
+	if !errors.As(err, &pinErr) {
+		t.Fatalf("Expected a pin mismatch, got: %v\n", err)
+	}
+	if !contains(pinErr.Presented, serverPin) || pinErr.ServerName != "127.0.0.1" {
+		t.Errorf("Pin mismatch error is missing details: %v\n", pinErr)
+	}
+	if len(client.ServerCertificates()) == 0 {
+		t.Error("Certificates presented by the server should be available after a pin mismatch.")
+	}
+
+	if _, err = connect(WithServerName("epp.domain.fi")); err == nil {
+		t.Error("Connecting with a server name not in the certificate should have failed.")
+	}
+
+	for _, opt := range []Option{WithPinnedKeys("not a pin"), WithMinTLSVersion(0x0200), WithCipherSuites(), WithServerName("")} {
+		if _, err = connect(opt); err == nil {
+			t.Error("Creating a client with an invalid TLS option should have failed.")
+		}
+	}
+}
+
+func TestParseTLSSettings(t *testing.T) {
+	if version, err := ParseTLSVersion("1.3"); err != nil || version != tls.VersionTLS13 {
+		t.Errorf("Unexpected TLS version: %#x, %v\n", version, err)
+	}
+	if _, err := ParseTLSVersion("2.0"); err == nil {
+		t.Error("Parsing an unknown TLS version should have failed.")
+	}
+
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"})
+	if err != nil || len(suites) != 1 || suites[0] != tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 {
+		t.Errorf("Unexpected cipher suites: %v, %v\n", suites, err)
+	}
+	if _, err = ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"}); err == nil {
+		t.Error("Insecure cipher suite should have been rejected.")
+	}
+}
@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"github.com/pkg/errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransactionIDGenerator creates the client transaction IDs (clTRID) sent
+// with each command. IDs should be unique across all clients using the same
+// registry account, and must be 3-64 characters long.
+type TransactionIDGenerator interface {
+	NewTransactionID() string
+}
+
+// Lengths of transaction IDs allowed by RFC 5730.
+const (
+	minTransactionIDLength = 3
+	maxTransactionIDLength = 64
+)
+
+const randomIDChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+const randomIDLength = 16
+
+// RandomIDGenerator creates IDs of Length random uppercase letters and
+// numbers using crypto/rand. It is the default generator, with IDs of 16
+// characters when Length is zero. Lengths outside 3-64 are clamped to it.
+type RandomIDGenerator struct {
+	Length int
+}
+
+func (g RandomIDGenerator) NewTransactionID() string {
+	length := g.Length
+	switch {
+	case length <= 0:
+		length = randomIDLength
+	case length < minTransactionIDLength:
+		length = minTransactionIDLength
+	case length > maxTransactionIDLength:
+		length = maxTransactionIDLength
+	}
+
+	max := big.NewInt(int64(len(randomIDChars)))
+	id := make([]byte, length)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// Reading from the system random source does not fail in practice.
+			panic(errors.Wrap(err, "Unable to generate transaction ID"))
+		}
+		id[i] = randomIDChars[n.Int64()]
+	}
+
+	return string(id)
+}
+
+// CounterIDGenerator creates IDs from a prefix and a counter increasing with
+// each ID, e.g. APP1-1, APP1-2 and so on. The prefix should identify the
+// process, so that IDs stay unique when the counter starts over. The zero
+// value has no prefix and zero-pads its IDs to three digits, e.g. 001.
+type CounterIDGenerator struct {
+	prefix  string
+	counter atomic.Uint64
+}
+
+// counterDigits is the length of the largest counter value.
+const counterDigits = 20
+
+// NewCounterIDGenerator fails if the prefix is too short for the first IDs or
+// too long for the last ones to be 3-64 characters long.
+func NewCounterIDGenerator(prefix string) (*CounterIDGenerator, error) {
+	if len(prefix)+1 < minTransactionIDLength || len(prefix)+counterDigits > maxTransactionIDLength {
+		return nil, errors.Errorf("Transaction ID prefix must be %d-%d characters long.", minTransactionIDLength-1, maxTransactionIDLength-counterDigits)
+	}
+
+	return &CounterIDGenerator{prefix: prefix}, nil
+}
+
+func (g *CounterIDGenerator) NewTransactionID() string {
+	width := minTransactionIDLength - len(g.prefix)
+	if width < 0 {
+		width = 0
+	}
+
+	return fmt.Sprintf("%s%0*d", g.prefix, width, g.counter.Add(1))
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// TimeSortableIDGenerator creates 26 character IDs in the ULID format: a
+// millisecond timestamp followed by random bits. IDs sort by their creation
+// time, and IDs created within the same millisecond are kept in order by
+// incrementing the random part.
+type TimeSortableIDGenerator struct {
+	mu      sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+}
+
+func NewTimeSortableIDGenerator() *TimeSortableIDGenerator {
+	return &TimeSortableIDGenerator{}
+}
+
+func (g *TimeSortableIDGenerator) NewTransactionID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms > g.lastMs {
+		g.lastMs = ms
+		if _, err := rand.Read(g.entropy[:]); err != nil {
+			panic(errors.Wrap(err, "Unable to generate transaction ID"))
+		}
+	} else {
+		// Same millisecond (or the clock went back): increment the previous
+		// entropy to keep IDs ordered.
+		for i := len(g.entropy) - 1; i >= 0; i-- {
+			g.entropy[i]++
+			if g.entropy[i] != 0 {
+				break
+			}
+		}
+	}
+
+	var raw [16]byte
+	binary.BigEndian.PutUint16(raw[0:2], uint16(g.lastMs>>32))
+	binary.BigEndian.PutUint32(raw[2:6], uint32(g.lastMs))
+	copy(raw[6:], g.entropy[:])
+
+	return encodeCrockford(raw)
+}
+
+// encodeCrockford encodes 128 bits as 26 characters of Crockford's base32,
+// the first character holding only the two highest bits.
+func encodeCrockford(raw [16]byte) string {
+	hi := binary.BigEndian.Uint64(raw[0:8])
+	lo := binary.BigEndian.Uint64(raw[8:16])
+
+	id := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		id[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(id)
+}
+
+type transactionKey struct{}
+
+// WithTransaction returns a context that records the transaction IDs of the
+// command sent with it to trID, for correlating own logs with the registry's.
+// Both IDs are recorded once a response has been received. If the command
+// is sent again after reconnecting, the IDs of the last attempt are kept.
+func WithTransaction(ctx context.Context, trID *epp.Transaction) context.Context {
+	return context.WithValue(ctx, transactionKey{}, trID)
+}
+
+func recordTransaction(ctx context.Context, payload, apiResp []byte) {
+	trID, ok := ctx.Value(transactionKey{}).(*epp.Transaction)
+	if !ok || trID == nil {
+		return
+	}
+
+	trID.ClTRID = summarizeMessage(payload).ClTRID
+	if apiResp != nil {
+		trID.SvTRID = summarizeMessage(apiResp).SvTRID
+	}
+}
+
+// SetTransactionIDGenerator replaces the generator used for client
+// transaction IDs.
+func (s *Client) SetTransactionIDGenerator(generator TransactionIDGenerator) error {
+	if generator == nil {
+		return errors.New("Transaction ID generator is required.")
+	}
+
+	s.stateMu.Lock()
+	s.idGenerator = generator
+	s.stateMu.Unlock()
+
+	return nil
+}
+
+func (s *Client) newTransactionID() string {
+	s.stateMu.RLock()
+	generator := s.idGenerator
+	s.stateMu.RUnlock()
+
+	return generator.NewTransactionID()
+}
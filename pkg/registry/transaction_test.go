@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTransactionIDGenerators(t *testing.T) {
+	counter, err := NewCounterIDGenerator("APP1-")
+	if err != nil {
+		t.Fatalf("Creating counter generator failed: %v\n", err)
+	}
+
+	generators := map[string]TransactionIDGenerator{
+		"random":        RandomIDGenerator{},
+		"counter":       counter,
+		"time sortable": NewTimeSortableIDGenerator(),
+	}
+
+	for name, generator := range generators {
+		seen := make(map[string]bool)
+		for i := 0; i < 1000; i++ {
+			id := generator.NewTransactionID()
+			if len(id) < 3 || len(id) > 64 {
+				t.Errorf("%s: ID %s is not 3-64 characters long.\n", name, id)
+			}
+			if seen[id] {
+				t.Errorf("%s: ID %s was generated twice.\n", name, id)
+			}
+			seen[id] = true
+		}
+	}
+
+	if id := (RandomIDGenerator{Length: 8}).NewTransactionID(); !regexp.MustCompile("^[A-Z0-9]{8}$").MatchString(id) {
+		t.Errorf("Unexpected random ID: %s\n", id)
+	}
+
+	for _, length := range []int{1, 100} {
+		if id := (RandomIDGenerator{Length: length}).NewTransactionID(); len(id) < 3 || len(id) > 64 {
+			t.Errorf("Random ID of length %d should have been clamped to 3-64 characters: %s\n", length, id)
+		}
+	}
+
+	if counter, err = NewCounterIDGenerator("APP1-"); err != nil {
+		t.Fatalf("Creating counter generator failed: %v\n", err)
+	}
+	if first, second := counter.NewTransactionID(), counter.NewTransactionID(); first != "APP1-1" || second != "APP1-2" {
+		t.Errorf("Unexpected counter IDs: %s, %s\n", first, second)
+	}
+
+	var zero CounterIDGenerator
+	if first, second := zero.NewTransactionID(), zero.NewTransactionID(); first != "001" || second != "002" {
+		t.Errorf("Unexpected IDs from the zero value: %s, %s\n", first, second)
+	}
+
+	for _, prefix := range []string{"", "A", strings.Repeat("A", 45)} {
+		if _, err = NewCounterIDGenerator(prefix); err == nil {
+			t.Errorf("Prefix of %d characters should have been rejected.\n", len(prefix))
+		}
+	}
+
+	sortable := NewTimeSortableIDGenerator()
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = sortable.NewTransactionID()
+		if !regexp.MustCompile("^[0-9A-HJKMNP-TV-Z]{26}$").MatchString(ids[i]) {
+			t.Fatalf("Unexpected time sortable ID: %s\n", ids[i])
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Error("Time sortable IDs are not in creation order.")
+	}
+}
+
+func TestWithTransaction(t *testing.T) {
+	eppTestServer, eppTestClient, err := initTestServerClient(12010)
+	if err != nil {
+		t.Fatalf("Error when creating server or client for tests: %v\n", err)
+	}
+	defer eppTestServer.Close()
+
+	if err = eppTestClient.SetTransactionIDGenerator(nil); err == nil {
+		t.Error("Setting a nil generator should have failed.")
+	}
+	counter, err := NewCounterIDGenerator("TRACE")
+	if err != nil {
+		t.Fatalf("Creating counter generator failed: %v\n", err)
+	}
+	if err = eppTestClient.SetTransactionIDGenerator(counter); err != nil {
+		t.Fatalf("Setting generator failed: %v\n", err)
+	}
+
+	if err = eppTestClient.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+
+	eppTestServer.SetupNewResponses(expectedDomainInfo, domainInfoResponse, failedCommand)
+
+	var trID epp.Transaction
+	ctx := WithTransaction(context.Background(), &trID)
+	if _, err = eppTestClient.GetDomainContext(ctx, "testdomain2.fi"); err != nil {
+		t.Fatalf("Fetching domain failed: %v\n", err)
+	}
+
+	if trID.ClTRID != "TRACE1" || trID.SvTRID != "54322-XYZ" {
+		t.Errorf("Unexpected transaction IDs: %+v\n", trID)
+	}
+
+	if err = eppTestClient.Close(); err != nil {
+		t.Fatalf("Closing the connection failed: %v\n", err)
+	}
+}
@@ -0,0 +1,303 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of transcript entries.
+const (
+	TranscriptConnect = "connect"
+	TranscriptSend    = "send"
+	TranscriptReceive = "receive"
+	TranscriptClose   = "close"
+)
+
+// TranscriptEntry is an event of a recorded session. Transcripts are written
+// as JSON, one entry per line.
+type TranscriptEntry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Payload is the frame sent or received, with passwords, keys and
+	// personal data masked.
+	Payload string `json:"payload,omitempty"`
+}
+
+// Command returns the name of the EPP command sent in the entry, e.g. login
+// or info.
+func (e TranscriptEntry) Command() string {
+	return summarizeMessage([]byte(e.Payload)).Command
+}
+
+var ErrTranscriptEnd = errors.New("Transcript has no more connections to replay.")
+
+// ReadTranscript reads the entries of a transcript written by
+// RecordingTransport.
+func ReadTranscript(r io.Reader) ([]TranscriptEntry, error) {
+	var entries []TranscriptEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, DefaultMaxFrameSize*2)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var entry TranscriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrapf(err, "Invalid transcript entry on line %d", line)
+		}
+		switch entry.Kind {
+		case TranscriptConnect, TranscriptSend, TranscriptReceive, TranscriptClose:
+		default:
+			return nil, errors.Errorf("Unknown transcript entry %q on line %d", entry.Kind, line)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// RecordingTransport records the frames exchanged over Transport to a
+// transcript, which can be replayed with ReplayTransport. Secrets are masked
+// in the transcript as in debug logs, also with WithUnsafeDebugLogging.
+//
+// Failing to write the transcript does not interrupt the session. The first
+// failure is returned by Err.
+type RecordingTransport struct {
+	Transport Transport
+
+	mu        sync.Mutex
+	encoder   *json.Encoder
+	err       error
+	connected bool
+}
+
+func NewRecordingTransport(transport Transport, w io.Writer) *RecordingTransport {
+	return &RecordingTransport{Transport: transport, encoder: json.NewEncoder(w)}
+}
+
+func (t *RecordingTransport) Connect(ctx context.Context) error {
+	if err := t.Transport.Connect(ctx); err != nil {
+		return err
+	}
+
+	t.connected = true
+	t.record(TranscriptConnect, nil)
+	return nil
+}
+
+func (t *RecordingTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	frame, err := t.Transport.ReadFrame(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.record(TranscriptReceive, frame)
+	return frame, nil
+}
+
+func (t *RecordingTransport) WriteFrame(ctx context.Context, payload []byte) error {
+	if err := t.Transport.WriteFrame(ctx, payload); err != nil {
+		return err
+	}
+
+	t.record(TranscriptSend, payload)
+	return nil
+}
+
+// Close records the end of the connection, if one was open.
+func (t *RecordingTransport) Close() error {
+	if t.connected {
+		t.connected = false
+		t.record(TranscriptClose, nil)
+	}
+
+	return t.Transport.Close()
+}
+
+// ConnectionState returns the TLS state of the recorded transport, if it has
+// one.
+func (t *RecordingTransport) ConnectionState() tls.ConnectionState {
+	if stater, ok := t.Transport.(connectionStater); ok {
+		return stater.ConnectionState()
+	}
+
+	return tls.ConnectionState{}
+}
+
+// PeerCertificates returns the certificates presented by the server to the
+// recorded transport, if it keeps them.
+func (t *RecordingTransport) PeerCertificates() []*x509.Certificate {
+	if certer, ok := t.Transport.(peerCertificater); ok {
+		return certer.PeerCertificates()
+	}
+
+	return nil
+}
+
+// Err returns the first error writing the transcript.
+func (t *RecordingTransport) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.err
+}
+
+func (t *RecordingTransport) record(kind string, payload []byte) {
+	entry := TranscriptEntry{Time: time.Now(), Kind: kind}
+	if payload != nil {
+		entry.Payload = redactMessage(payload)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.encoder.Encode(entry); err != nil && t.err == nil {
+		t.err = errors.Wrap(err, "Writing transcript failed")
+	}
+}
+
+// ReplayTransport serves the responses of a recorded transcript to a client,
+// e.g. to reproduce an issue seen with the registry. Each Connect starts the
+// next recorded connection with its greeting. Frames written by the client
+// must be the same commands as recorded, in the same order, and the
+// transaction IDs of the recorded responses are replaced with the ones the
+// client sent.
+type ReplayTransport struct {
+	entries   []TranscriptEntry
+	pos       int
+	connected bool
+	// clTRIDs maps the recorded transaction IDs to the replayed ones.
+	clTRIDs map[string]string
+}
+
+func NewReplayTransport(entries []TranscriptEntry) *ReplayTransport {
+	return &ReplayTransport{entries: entries, clTRIDs: make(map[string]string)}
+}
+
+func (t *ReplayTransport) Connect(ctx context.Context) error {
+	if t.connected {
+		return errors.New("Transport is already connected.")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for ; t.pos < len(t.entries); t.pos++ {
+		if t.entries[t.pos].Kind == TranscriptConnect {
+			t.pos++
+			t.connected = true
+			return nil
+		}
+	}
+
+	return ErrTranscriptEnd
+}
+
+// ReadFrame returns the next recorded response. When the recorded connection
+// ends, io.EOF is returned as if the server had closed it.
+func (t *ReplayTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	if !t.connected {
+		return nil, ErrNotConnected
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	entry, ok := t.next()
+	if !ok {
+		return nil, io.EOF
+	}
+	if entry.Kind != TranscriptReceive {
+		return nil, errors.New("Transcript expects a command to be sent before reading a response.")
+	}
+	t.pos++
+
+	payload := entry.Payload
+	recorded := summarizeMessage([]byte(payload)).ClTRID
+	if replayed, ok := t.clTRIDs[recorded]; ok && recorded != "" {
+		payload = strings.Replace(payload, "<clTRID>"+recorded+"</clTRID>", "<clTRID>"+replayed+"</clTRID>", 1)
+	}
+
+	return []byte(payload), nil
+}
+
+// WriteFrame checks that payload is the command recorded next.
+func (t *ReplayTransport) WriteFrame(ctx context.Context, payload []byte) error {
+	if !t.connected {
+		return ErrNotConnected
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry, ok := t.next()
+	if !ok {
+		return io.EOF
+	}
+	if entry.Kind != TranscriptSend {
+		return errors.New("Transcript expects a response to be read before sending a command.")
+	}
+
+	sent := summarizeMessage(payload)
+	recorded := summarizeMessage([]byte(entry.Payload))
+	if sent.Command != recorded.Command || sent.Op != recorded.Op {
+		return errors.Errorf("Sent command %s does not match the recorded command %s.", sent.Command, recorded.Command)
+	}
+	t.pos++
+
+	if recorded.ClTRID != "" {
+		t.clTRIDs[recorded.ClTRID] = sent.ClTRID
+	}
+
+	return nil
+}
+
+// Close ends the current recorded connection, skipping its remaining frames.
+func (t *ReplayTransport) Close() error {
+	if !t.connected {
+		return nil
+	}
+
+	for t.pos < len(t.entries) && t.entries[t.pos].Kind != TranscriptConnect {
+		t.pos++
+	}
+	t.connected = false
+
+	return nil
+}
+
+// Remaining returns the recorded commands not yet sent.
+func (t *ReplayTransport) Remaining() int {
+	remaining := 0
+	for _, entry := range t.entries[t.pos:] {
+		if entry.Kind == TranscriptSend {
+			remaining++
+		}
+	}
+
+	return remaining
+}
+
+// next returns the next frame of the current connection.
+func (t *ReplayTransport) next() (TranscriptEntry, bool) {
+	if t.pos >= len(t.entries) {
+		return TranscriptEntry{}, false
+	}
+
+	entry := t.entries[t.pos]
+	if entry.Kind == TranscriptConnect || entry.Kind == TranscriptClose {
+		return TranscriptEntry{}, false
+	}
+
+	return entry, true
+}
@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_RecordAndReplay(t *testing.T) {
+	var connections atomic.Int32
+	var transcript bytes.Buffer
+
+	client, err := New(
+		WithCredentials("test", "secret123"),
+		WithTransport(&PipeTransport{Serve: servePipe(0, &connections)}),
+		WithTranscript(&transcript),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	if err = client.Login(); err != nil {
+		t.Fatalf("Login failed: %v\n", err)
+	}
+	if _, err = client.Hello(); err != nil {
+		t.Fatalf("Hello failed: %v\n", err)
+	}
+	if err = client.Close(); err != nil {
+		t.Fatalf("Closing failed: %v\n", err)
+	}
+
+	if strings.Contains(transcript.String(), "secret123") {
+		t.Error("Password should have been masked in the transcript.")
+	}
+
+	entries, err := ReadTranscript(&transcript)
+	if err != nil {
+		t.Fatalf("Reading transcript failed: %v\n", err)
+	}
+	if len(entries) < 6 || entries[0].Kind != TranscriptConnect || entries[len(entries)-1].Kind != TranscriptClose {
+		t.Fatalf("Unexpected transcript: %+v\n", entries)
+	}
+
+	replay := NewReplayTransport(entries)
+	replayed, err := New(WithCredentials("test", "other123"), WithTransport(replay))
+	if err != nil {
+		t.Fatalf("Creating replaying client failed: %v\n", err)
+	}
+	if err = replayed.Connect(); err != nil {
+		t.Fatalf("Connecting to replay failed: %v\n", err)
+	}
+	if replayed.ServerGreeting().SvID != client.ServerGreeting().SvID {
+		t.Error("Replayed greeting should match the recorded one.")
+	}
+
+	// Transaction IDs differ from the recorded ones, but the responses are
+	// matched to the replayed commands.
+	if err = replayed.Login(); err != nil {
+		t.Fatalf("Replayed login failed: %v\n", err)
+	}
+	if _, err = replayed.Poll(); err == nil {
+		t.Error("Command not in the transcript should have failed.")
+	}
+
+	if err = replayed.Close(); err != nil {
+		t.Errorf("Closing replay failed: %v\n", err)
+	}
+	if err = replayed.Connect(); err != ErrTranscriptEnd {
+		t.Errorf("Connecting past the transcript should have failed, got: %v\n", err)
+	}
+}
+
+func TestClient_TranscriptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	// A failed connection attempt leaves nothing to replay.
+	failing, err := New(
+		WithCredentials("test", "test123"),
+		WithTransport(&PipeTransport{}),
+		WithTranscriptFile(path),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = failing.Connect(); err == nil {
+		t.Fatal("Connecting without a server should have failed.")
+	}
+	if err = failing.Close(); err != nil {
+		t.Fatalf("Closing failed: %v\n", err)
+	}
+
+	var connections atomic.Int32
+	client, err := New(
+		WithCredentials("test", "test123"),
+		WithTransport(&PipeTransport{Serve: servePipe(0, &connections)}),
+		WithTranscriptFile(path),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting failed: %v\n", err)
+	}
+	transcriptFile := client.transcriptFile
+	if err = client.Close(); err != nil {
+		t.Fatalf("Closing failed: %v\n", err)
+	}
+	if err = transcriptFile.Close(); err == nil {
+		t.Error("Transcript file should have been closed with the client.")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Opening transcript failed: %v\n", err)
+	}
+	defer file.Close()
+
+	entries, err := ReadTranscript(file)
+	if err != nil {
+		t.Fatalf("Reading transcript failed: %v\n", err)
+	}
+	if len(entries) != 3 || entries[0].Kind != TranscriptConnect || entries[2].Kind != TranscriptClose {
+		t.Errorf("Unexpected transcript: %+v\n", entries)
+	}
+
+	if _, err = New(WithTranscriptFile("")); err == nil {
+		t.Error("Empty transcript path should have failed.")
+	}
+}
+
+func TestReadTranscript(t *testing.T) {
+	valid := `{"time":"2024-01-01T00:00:00Z","kind":"connect"}
+
+{"time":"2024-01-01T00:00:01Z","kind":"receive","payload":"<epp/>"}
+`
+	entries, err := ReadTranscript(strings.NewReader(valid))
+	if err != nil {
+		t.Fatalf("Reading transcript failed: %v\n", err)
+	}
+	if len(entries) != 2 || entries[1].Payload != "<epp/>" {
+		t.Errorf("Unexpected entries: %+v\n", entries)
+	}
+
+	if _, err = ReadTranscript(strings.NewReader(`{"kind":"unknown"}`)); err == nil {
+		t.Error("Unknown entry kind should have failed.")
+	}
+	if _, err = ReadTranscript(strings.NewReader(`not json`)); err == nil {
+		t.Error("Invalid JSON should have failed.")
+	}
+}
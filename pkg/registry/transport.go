@@ -0,0 +1,235 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Transport carries EPP frames between the client and the registry. The
+// client calls Connect when connecting and reconnecting, and Close when
+// closing the connection, also after a read or write failed and left the
+// connection in an unknown state. Calls are never made concurrently.
+//
+// TLSTransport is used by default. PipeTransport connects the client to an
+// in-memory server, e.g. for unit tests.
+type Transport interface {
+	// Connect opens a new connection. The server greeting is read from it
+	// with ReadFrame.
+	Connect(ctx context.Context) error
+	// ReadFrame returns the payload of the next frame. It must return when
+	// ctx is done.
+	ReadFrame(ctx context.Context) ([]byte, error)
+	// WriteFrame sends payload as a single frame. It must return when ctx
+	// is done.
+	WriteFrame(ctx context.Context, payload []byte) error
+	Close() error
+}
+
+// connectionStater is implemented by transports connecting over TLS, whose
+// server certificates the client checks for expiry.
+type connectionStater interface {
+	ConnectionState() tls.ConnectionState
+}
+
+// peerCertificater is implemented by transports that keep the certificates
+// presented by the server also when connecting to it failed.
+type peerCertificater interface {
+	PeerCertificates() []*x509.Certificate
+}
+
+// TLSTransport connects to the registry over TLS, the transport defined for
+// EPP in RFC 5734.
+type TLSTransport struct {
+	// Address is the host and port of the registry server.
+	Address string
+	Config  *tls.Config
+	// Dialer opens the TCP connection, *net.Dialer if nil.
+	Dialer       Dialer
+	MaxFrameSize int64
+
+	conn      *tls.Conn
+	peerCerts []*x509.Certificate
+}
+
+func (t *TLSTransport) Connect(ctx context.Context) error {
+	if t.conn != nil {
+		return errors.New("Transport is already connected.")
+	}
+
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	t.peerCerts = nil
+	rawConn, err := dialer.DialContext(ctx, "tcp", t.Address)
+	if err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(rawConn, t.Config)
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		_ = rawConn.Close()
+
+		// The presented chain is kept for diagnosing the failure, whether it
+		// did not verify or was rejected by VerifyConnection, e.g. pinning.
+		var verifyErr *tls.CertificateVerificationError
+		if errors.As(err, &verifyErr) {
+			t.peerCerts = verifyErr.UnverifiedCertificates
+		} else {
+			t.peerCerts = tlsConn.ConnectionState().PeerCertificates
+		}
+		return err
+	}
+	t.conn = tlsConn
+	t.peerCerts = tlsConn.ConnectionState().PeerCertificates
+
+	return nil
+}
+
+func (t *TLSTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	if t.conn == nil {
+		return nil, ErrNotConnected
+	}
+
+	return readConnFrame(ctx, t.conn, t.MaxFrameSize)
+}
+
+func (t *TLSTransport) WriteFrame(ctx context.Context, payload []byte) error {
+	if t.conn == nil {
+		return ErrNotConnected
+	}
+
+	return writeConnFrame(ctx, t.conn, payload)
+}
+
+func (t *TLSTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+// ConnectionState returns the TLS state of the current connection, including
+// the certificates presented by the server.
+func (t *TLSTransport) ConnectionState() tls.ConnectionState {
+	if t.conn == nil {
+		return tls.ConnectionState{}
+	}
+
+	return t.conn.ConnectionState()
+}
+
+// PeerCertificates returns the certificates presented by the server on the
+// last connection attempt, also when the handshake failed.
+func (t *TLSTransport) PeerCertificates() []*x509.Certificate {
+	return t.peerCerts
+}
+
+// PipeTransport connects the client to a server running in the same process
+// over net.Pipe, without certificates or network ports. Each connection
+// calls Serve in a new goroutine with the server end of the pipe. The
+// server frames its messages with WriteFrame and reads the client's with
+// ReadFrame, starting by sending the greeting.
+type PipeTransport struct {
+	Serve        func(conn net.Conn)
+	MaxFrameSize int64
+
+	conn net.Conn
+	wg   sync.WaitGroup
+}
+
+func (t *PipeTransport) Connect(ctx context.Context) error {
+	if t.Serve == nil {
+		return errors.New("Pipe transport has no server.")
+	}
+	if t.conn != nil {
+		return errors.New("Transport is already connected.")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	clientConn, serverConn := net.Pipe()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer serverConn.Close()
+		t.Serve(serverConn)
+	}()
+	t.conn = clientConn
+
+	return nil
+}
+
+func (t *PipeTransport) ReadFrame(ctx context.Context) ([]byte, error) {
+	if t.conn == nil {
+		return nil, ErrNotConnected
+	}
+
+	return readConnFrame(ctx, t.conn, t.MaxFrameSize)
+}
+
+func (t *PipeTransport) WriteFrame(ctx context.Context, payload []byte) error {
+	if t.conn == nil {
+		return ErrNotConnected
+	}
+
+	return writeConnFrame(ctx, t.conn, payload)
+}
+
+func (t *PipeTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+// Wait waits for the servers of all connections to return.
+func (t *PipeTransport) Wait() {
+	t.wg.Wait()
+}
+
+// readConnFrame reads a frame from conn, interrupting the read when ctx is
+// done or its deadline passes.
+func readConnFrame(ctx context.Context, conn net.Conn, limit int64) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+	} else {
+		_ = conn.SetReadDeadline(time.Time{})
+	}
+	stop := interruptOnDone(ctx, conn)
+	defer stop()
+
+	return ReadFrame(conn, limit)
+}
+
+func writeConnFrame(ctx context.Context, conn net.Conn, payload []byte) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	} else {
+		_ = conn.SetWriteDeadline(time.Time{})
+	}
+	stop := interruptOnDone(ctx, conn)
+	defer stop()
+
+	return WriteFrame(conn, payload)
+}
+
+// interruptOnDone unblocks any pending I/O on conn when ctx is done.
+// The returned function stops the watch and must be called once I/O is over.
+func interruptOnDone(ctx context.Context, conn net.Conn) func() bool {
+	return context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+}
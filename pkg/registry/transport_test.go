@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var clTRIDPattern = regexp.MustCompile(`<clTRID>([^<]*)</clTRID>`)
+
+// servePipe answers to login, logout and hello, and drops the connection after
+// dropAfter requests if it is positive.
+func servePipe(dropAfter int, connections *atomic.Int32) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		connections.Add(1)
+		if err := WriteFrame(conn, []byte(greeting)); err != nil {
+			return
+		}
+
+		for requests := 1; ; requests++ {
+			req, err := ReadFrame(conn, 0)
+			if err != nil {
+				return
+			}
+			if dropAfter > 0 && requests > dropAfter {
+				return
+			}
+
+			var clTRID string
+			if match := clTRIDPattern.FindSubmatch(req); match != nil {
+				clTRID = string(match[1])
+			}
+
+			response := successfulCommandResponse
+			switch {
+			case strings.Contains(string(req), "<hello"):
+				response = greeting
+			case strings.Contains(string(req), "<login>"):
+				response = successfulLogin
+			case strings.Contains(string(req), "<logout"):
+				response = successfulLogout
+			}
+			if err = WriteFrame(conn, []byte(strings.Replace(response, "REPLACE_REQ_ID", clTRID, 1))); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestClient_PipeTransport(t *testing.T) {
+	var connections atomic.Int32
+	transport := &PipeTransport{Serve: servePipe(2, &connections)}
+
+	client, err := New(
+		WithCredentials("test", "test123"),
+		WithTransport(transport),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 1}),
+	)
+	if err != nil {
+		t.Fatalf("Creating client failed: %v\n", err)
+	}
+
+	if err = client.Connect(); err != nil {
+		t.Fatalf("Connecting over pipe failed: %v\n", err)
+	}
+	if client.ServerGreeting().SvID == "" {
+		t.Error("Greeting should have been read from the pipe.")
+	}
+
+	if err = client.Login(); err != nil {
+		t.Fatalf("Login over pipe failed: %v\n", err)
+	}
+	if _, err = client.Hello(); err != nil {
+		t.Fatalf("Hello over pipe failed: %v\n", err)
+	}
+
+	// The server drops the connection, and the client reconnects over a
+	// new pipe, logging in again before repeating the hello.
+	if _, err = client.Hello(); err != nil {
+		t.Fatalf("Hello after reconnecting failed: %v\n", err)
+	}
+	if connections.Load() != 2 || !client.IsLoggedIn() {
+		t.Errorf("Client should have reconnected and logged in, connections: %d\n", connections.Load())
+	}
+
+	if err = client.Close(); err != nil {
+		t.Errorf("Closing failed: %v\n", err)
+	}
+	transport.Wait()
+
+	if _, err = New(WithCredentials("test", "test123"), WithTransport(nil)); err == nil {
+		t.Error("Nil transport should have failed.")
+	}
+}
@@ -1,20 +1,81 @@
 package registry
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"github.com/ajmyyra/go-epp-fi/pkg/epp"
 	"github.com/pkg/errors"
-	"math/rand"
+	"log/slog"
+	"strconv"
 	"time"
 )
 
-const reqIDChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-const reqIDLength = 5
+// messageSummary holds the parts of a request or response the client needs
+// to know about before the message itself is unmarshalled.
+type messageSummary struct {
+	Command    string
+	Op         string
+	ResultCode epp.ResultCode
+	epp.Transaction
+}
+
+// summarizeMessage picks the command, first result code and transaction IDs
+// from a raw request or response without unmarshalling the whole message.
+func summarizeMessage(message []byte) messageSummary {
+	var summary messageSummary
+
+	decoder := xml.NewDecoder(bytes.NewReader(message))
+	parent := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return summary
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case start.Name.Local == "clTRID":
+			_ = decoder.DecodeElement(&summary.ClTRID, &start)
+		case start.Name.Local == "svTRID":
+			_ = decoder.DecodeElement(&summary.SvTRID, &start)
+		case start.Name.Local == "hello":
+			summary.Command = "hello"
+		case start.Name.Local == "result" && summary.ResultCode == 0:
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "code" {
+					code, _ := strconv.Atoi(attr.Value)
+					summary.ResultCode = epp.ResultCode(code)
+				}
+			}
+		case parent == "command" && summary.Command == "":
+			summary.Command = start.Name.Local
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "op" {
+					summary.Op = attr.Value
+				}
+			}
+		}
 
-func createRequestID(length int) string {
-	reqID := make([]byte, length)
-	for i := range reqID {
-		reqID[i] = reqIDChars[rand.Intn(len(reqIDChars))]
+		parent = start.Name.Local
 	}
-	return string(reqID)
+}
+
+// isIdempotent tells whether the command in a request can safely be sent
+// again without knowing if the server already executed it.
+func (m messageSummary) isIdempotent() bool {
+	switch m.Command {
+	case "hello", "check", "info":
+		return true
+	case "poll":
+		return m.Op == "req"
+	}
+
+	return false
 }
 
 func parseDate(rawDate string) (time.Time, error) {
@@ -48,7 +109,38 @@ func parseDate(rawDate string) (time.Time, error) {
 	return time.Time{}, errors.New("Unrecognised date format: " + rawDate)
 }
 
-func (s *Client) logAPIConnectionError(err error, args ...string) {
-	s.log.Error("API connection failed when making a request", "error", err, args)
+// logRequest logs the outcome of a request: failures to get a response as
+// errors, commands rejected by the server as info and others as debug.
+func (s *Client) logRequest(ctx context.Context, payload, apiResp []byte, err error, duration time.Duration) {
+	request := summarizeMessage(payload)
+	attrs := []slog.Attr{
+		slog.String("command", request.Command),
+		slog.String("clTRID", request.ClTRID),
+		slog.Duration("duration", duration),
+	}
+	if request.Op != "" {
+		attrs = append(attrs, slog.String("op", request.Op))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		s.log.LogAttrs(ctx, slog.LevelError, "Request to registry failed.", attrs...)
+		return
+	}
+
+	response := summarizeMessage(apiResp)
+	attrs = append(attrs, slog.String("svTRID", response.SvTRID))
+	if response.ResultCode == 0 {
+		// Greeting as a response to hello has no result.
+		s.log.LogAttrs(ctx, slog.LevelDebug, "Request completed.", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.Int("resultCode", int(response.ResultCode)))
+	if !response.ResultCode.IsSuccess() {
+		s.log.LogAttrs(ctx, slog.LevelInfo, "Request was rejected by registry.", attrs...)
+		return
+	}
+	s.log.LogAttrs(ctx, slog.LevelDebug, "Request completed.", attrs...)
 }
 
@@ -11,7 +11,6 @@ import (
 	"net"
 	"regexp"
 	"strings"
-	"time"
 )
 
 func initTestServerClient(serverPort int) (EPPTestServer, *Client, error) {
@@ -50,8 +49,6 @@ func createEPPTestClient(user, password, serverHost string, serverPort int) (*Cl
 		return nil, errors.Wrap(err, "Problem setting CA certificates for the new client.")
 	}
 
-	client.SetSendWaitTime(time.Duration(10) * time.Microsecond)
-
 	return client, nil
 }
 
@@ -123,23 +120,12 @@ func (s *EPPTestServer) handleClientConnection(conn net.Conn) {
 	}
 
 	for {
-		var rawResponse int32
-
-		err = binary.Read(conn, binary.BigEndian, &rawResponse)
+		newReq, err := ReadFrame(conn, 0)
 		if err != nil {
 			// Client has closed the connection, so we can close it as well.
-			break
-		}
-
-		rawResponse -= 4
-		if rawResponse < 0 {
-			fmt.Println("Problem when reading from client: unexpectedEOF")
-			break
-		}
-
-		newReq, err := readStreamToBytes(conn, rawResponse)
-		if err != nil {
-			fmt.Println("Problem when reading client request: " + err.Error())
+			if _, ok := err.(*FrameError); ok {
+				fmt.Println("Problem when reading client request: " + err.Error())
+			}
 			break
 		}
 
@@ -172,6 +158,10 @@ func (s *EPPTestServer) handleClientConnection(conn net.Conn) {
 			response = errMsg
 		}
 
+		if string(response) == dropConnection {
+			break
+		}
+
 		sendBytesLength := uint32(4 + len(response))
 		err = binary.Write(conn, binary.BigEndian, sendBytesLength)
 		if err != nil {
@@ -218,6 +208,10 @@ func loadTestServerCerts() (tls.Certificate, *x509.CertPool, error) {
 	return cert, pool, nil
 }
 
+// Queuing dropConnection as a response makes the server close the
+// connection instead of responding.
+const dropConnection = "DROP_CONNECTION"
+
 var greeting = `<epp xmlns:obj="urn:ietf:params:xml:ns:obj-1.0" xmlns="urn:ietf:params:xml:ns:epp-1.0">
 <greeting>
   <svID>Ficora EPP Server</svID>